@@ -0,0 +1,178 @@
+package virtualbox
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+)
+
+// AudioDriver is the host audio backend of a machine.
+type AudioDriver string
+
+const (
+	// AudioNone disables the audio of the machine.
+	AudioNone = AudioDriver("none")
+	// AudioNull plays nothing, but keeps the guest audio device.
+	AudioNull = AudioDriver("null")
+	// AudioCoreAudio is the macOS driver.
+	AudioCoreAudio = AudioDriver("coreaudio")
+	// AudioPulse is the PulseAudio (or PipeWire) driver of Linux hosts.
+	AudioPulse = AudioDriver("pulse")
+	// AudioALSA is the ALSA driver of Linux hosts.
+	AudioALSA = AudioDriver("alsa")
+	// AudioOSS is the Open Sound System driver of Unix hosts.
+	AudioOSS = AudioDriver("oss")
+	// AudioDirectSound is the DirectSound driver of Windows hosts.
+	AudioDirectSound = AudioDriver("dsound")
+	// AudioAuto picks the driver of the host OS, see DetectAudioDriver.
+	AudioAuto = AudioDriver("auto")
+)
+
+// AudioController is the audio hardware emulated for the guest.
+type AudioController string
+
+const (
+	// AudioAC97 emulates an Intel AC'97 controller.
+	AudioAC97 = AudioController("ac97")
+	// AudioHDA emulates an Intel HD Audio controller.
+	AudioHDA = AudioController("hda")
+	// AudioSB16 emulates a SoundBlaster 16 card.
+	AudioSB16 = AudioController("sb16")
+)
+
+// AudioCodec is the codec emulated along with the audio controller.
+type AudioCodec string
+
+const (
+	// AudioCodecSTAC9700 is an AC'97 codec.
+	AudioCodecSTAC9700 = AudioCodec("stac9700")
+	// AudioCodecAD1980 is an AC'97 codec.
+	AudioCodecAD1980 = AudioCodec("ad1980")
+	// AudioCodecSTAC9221 is the HD Audio codec.
+	AudioCodecSTAC9221 = AudioCodec("stac9221")
+	// AudioCodecSB16 is the SoundBlaster 16 codec.
+	AudioCodecSB16 = AudioCodec("sb16")
+)
+
+// audioCodecs lists the codecs each controller supports.
+var audioCodecs = map[AudioController][]AudioCodec{
+	AudioAC97: {AudioCodecSTAC9700, AudioCodecAD1980},
+	AudioHDA:  {AudioCodecSTAC9221},
+	AudioSB16: {AudioCodecSB16},
+}
+
+// AudioConfig holds the audio settings of a machine. Zero fields are left
+// unchanged.
+type AudioConfig struct {
+	Driver     AudioDriver
+	Controller AudioController
+	Codec      AudioCodec // must suit Controller, when both are given
+}
+
+// pathExists is swapped by the tests to fake the host audio devices.
+var pathExists = func(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// DetectAudioDriver returns the audio driver suiting the host: CoreAudio on
+// macOS, DirectSound on Windows, and on Linux PulseAudio when a sound server
+// is found, else ALSA when there are sound devices. It falls back to
+// AudioNull, which keeps the guest audio device but plays nothing.
+func DetectAudioDriver() AudioDriver {
+	return detectAudioDriver(runtime.GOOS)
+}
+
+func detectAudioDriver(goos string) AudioDriver {
+	switch goos {
+	case "darwin":
+		return AudioCoreAudio
+	case osWindows:
+		return AudioDirectSound
+	case "linux":
+		if pulseAvailable() {
+			return AudioPulse
+		}
+		if pathExists("/dev/snd") {
+			return AudioALSA
+		}
+	default:
+		if pathExists("/dev/dsp") {
+			return AudioOSS
+		}
+	}
+	return AudioNull
+}
+
+// pulseAvailable tells whether a PulseAudio compatible sound server is
+// reachable, or could be started for the user.
+func pulseAvailable() bool {
+	if os.Getenv("PULSE_SERVER") != "" {
+		return true
+	}
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" && pathExists(filepath.Join(dir, "pulse", "native")) {
+		return true
+	}
+	for _, server := range []string{"pulseaudio", "pipewire-pulse"} {
+		if _, err := exec.LookPath(server); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// ConfigureAudio applies the audio settings of the machine, which must not be
+// running. AudioAuto is resolved with DetectAudioDriver on the host running
+// this code, which should be the VirtualBox host.
+func ConfigureAudio(vm string, cfg AudioConfig) error {
+	if cfg.Codec != "" && cfg.Controller != "" {
+		ok := false
+		for _, c := range audioCodecs[cfg.Controller] {
+			ok = ok || c == cfg.Codec
+		}
+		if !ok {
+			return fmt.Errorf("%w: audio codec '%s' does not suit controller '%s'", ErrInvalidArgument, cfg.Codec, cfg.Controller)
+		}
+	}
+	if cfg.Driver == AudioAuto {
+		cfg.Driver = DetectAudioDriver()
+	}
+	if err := assertMutable(vm); err != nil {
+		return err
+	}
+
+	// VirtualBox 7.0 renamed the options, and split disabling the audio off
+	// the driver.
+	modern, err := versionAtLeast(7, 0)
+	if err != nil {
+		return err
+	}
+	args := []string{"modifyvm", vm}
+	switch {
+	case cfg.Driver == "":
+	case !modern:
+		args = append(args, "--audio", string(cfg.Driver))
+	case cfg.Driver == AudioNone:
+		args = append(args, "--audio-enabled", "off")
+	default:
+		args = append(args, "--audio-enabled", "on", "--audio-driver", string(cfg.Driver))
+	}
+	opt := func(name string) string {
+		if modern {
+			return "--audio-" + name
+		}
+		return "--audio" + name
+	}
+	if cfg.Controller != "" {
+		args = append(args, opt("controller"), string(cfg.Controller))
+	}
+	if cfg.Codec != "" {
+		args = append(args, opt("codec"), string(cfg.Codec))
+	}
+	if len(args) == 2 {
+		return nil // nothing to change
+	}
+	return Manage().run(args...)
+}
@@ -0,0 +1,64 @@
+package virtualbox
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestDetectAudioDriver(t *testing.T) {
+	orig := pathExists
+	defer func() { pathExists = orig }()
+	var devices map[string]bool
+	pathExists = func(path string) bool { return devices[path] }
+
+	if d := detectAudioDriver("darwin"); d != AudioCoreAudio {
+		t.Errorf("expected coreaudio on macOS, got %s", d)
+	}
+	if d := detectAudioDriver(osWindows); d != AudioDirectSound {
+		t.Errorf("expected dsound on Windows, got %s", d)
+	}
+	devices = map[string]bool{"/dev/dsp": true}
+	if d := detectAudioDriver("freebsd"); d != AudioOSS {
+		t.Errorf("expected oss on FreeBSD, got %s", d)
+	}
+	devices = nil
+	if d := detectAudioDriver("freebsd"); d != AudioNull {
+		t.Errorf("expected the null driver without sound devices, got %s", d)
+	}
+}
+
+func TestConfigureAudio(t *testing.T) {
+	Setup(t)
+
+	if err := ConfigureAudio(VM, AudioConfig{Controller: AudioHDA, Codec: AudioCodecAD1980}); !errors.Is(err, ErrInvalidArgument) {
+		t.Fatalf("expected ErrInvalidArgument for a mismatched codec, got %v", err)
+	}
+	if ManageMock != nil {
+		vmInfoOut := ReadTestData("vboxmanage-showvminfo-poweroff.out")
+		gomock.InOrder(
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+			ManageMock.EXPECT().runOut("--version").Return("7.0.4r154605\n", nil).Times(1),
+			ManageMock.EXPECT().run("modifyvm", VM, "--audio-enabled", "on", "--audio-driver", "pulse",
+				"--audio-controller", "hda", "--audio-codec", "stac9221").Return(nil).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+			ManageMock.EXPECT().runOut("--version").Return("6.1.30r148432\n", nil).Times(1),
+			ManageMock.EXPECT().run("modifyvm", VM, "--audio", "none", "--audiocodec", "sb16").Return(nil).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+			ManageMock.EXPECT().runOut("--version").Return("7.0.4r154605\n", nil).Times(1),
+			ManageMock.EXPECT().run("modifyvm", VM, "--audio-enabled", "off").Return(nil).Times(1),
+		)
+		if err := ConfigureAudio(VM, AudioConfig{Driver: AudioPulse, Controller: AudioHDA, Codec: AudioCodecSTAC9221}); err != nil {
+			t.Fatal(err)
+		}
+		if err := ConfigureAudio(VM, AudioConfig{Driver: AudioNone, Codec: AudioCodecSB16}); err != nil {
+			t.Fatal(err)
+		}
+		if err := ConfigureAudio(VM, AudioConfig{Driver: AudioNone}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	Teardown()
+}
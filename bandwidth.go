@@ -0,0 +1,124 @@
+package virtualbox
+
+import (
+	"bufio"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	reBandwidthGroup = regexp.MustCompile(`^Name: '(.*)', Type: (\w+)`)
+	reBandwidthLimit = regexp.MustCompile(`^\d+[kmgKMG]?$`)
+)
+
+// BandwidthGroupType represents what a bandwidth group throttles.
+type BandwidthGroupType string
+
+const (
+	// BandwidthDisk when the group throttles disk I/O.
+	BandwidthDisk = BandwidthGroupType("Disk")
+	// BandwidthNetwork when the group throttles network I/O.
+	BandwidthNetwork = BandwidthGroupType("Network")
+)
+
+// BandwidthGroup is a bandwidth group of a machine.
+type BandwidthGroup struct {
+	Name string
+	Type BandwidthGroupType
+}
+
+// ListBandwidthGroups lists the bandwidth groups of the machine.
+func ListBandwidthGroups(vm string) ([]BandwidthGroup, error) {
+	out, err := Manage().runOut("bandwidthctl", vm, "list")
+	if err != nil {
+		return nil, err
+	}
+	var groups []BandwidthGroup
+	s := bufio.NewScanner(strings.NewReader(out))
+	for s.Scan() {
+		res := reBandwidthGroup.FindStringSubmatch(strings.TrimSpace(s.Text()))
+		if res == nil {
+			continue
+		}
+		groups = append(groups, BandwidthGroup{Name: res[1], Type: BandwidthGroupType(res[2])})
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
+
+// checkBandwidthGroup fails unless the machine has a bandwidth group of the
+// given name and type.
+func checkBandwidthGroup(vm, group string, typ BandwidthGroupType) error {
+	groups, err := ListBandwidthGroups(vm)
+	if err != nil {
+		return err
+	}
+	for _, g := range groups {
+		if g.Name != group {
+			continue
+		}
+		if g.Type != typ {
+			return fmt.Errorf("bandwidth group '%s' of '%s' is a %s group, not a %s one", group, vm, g.Type, typ)
+		}
+		return nil
+	}
+	return fmt.Errorf("machine '%s' has no bandwidth group '%s'", vm, group)
+}
+
+// runningBandwidthError explains the groups cannot be reassigned live.
+func runningBandwidthError(vm string, err error) error {
+	return fmt.Errorf("bandwidth groups of '%s' can only be reassigned when stopped, use SetBandwidthLimit to throttle it live: %w", vm, err)
+}
+
+// AssignNICBandwidthGroup assigns the n-th NIC (starting at 1) of the machine
+// to the given network bandwidth group, or to none if group is empty.
+// VirtualBox only permits it when the machine is not running; the limit of
+// the group itself can be changed live with SetBandwidthLimit.
+func AssignNICBandwidthGroup(vm string, n int, group string) error {
+	if err := checkNICIndex(n); err != nil {
+		return err
+	}
+	if group == "" {
+		group = "none"
+	} else if err := checkBandwidthGroup(vm, group, BandwidthNetwork); err != nil {
+		return err
+	}
+	if err := assertMutable(vm); err != nil {
+		return runningBandwidthError(vm, err)
+	}
+	return Manage().run("modifyvm", vm, fmt.Sprintf("--nicbandwidthgroup%d", n), group)
+}
+
+// AssignDiskBandwidthGroup assigns the medium attached to the given port and
+// device of the named storage controller to the given disk bandwidth group,
+// or to none if group is empty. Like AssignNICBandwidthGroup, the machine must
+// not be running.
+func AssignDiskBandwidthGroup(vm, ctlName string, port, device uint, group string) error {
+	if group == "" {
+		group = "none"
+	} else if err := checkBandwidthGroup(vm, group, BandwidthDisk); err != nil {
+		return err
+	}
+	if err := assertMutable(vm); err != nil {
+		return runningBandwidthError(vm, err)
+	}
+	return Manage().run("storageattach", vm, "--storagectl", ctlName,
+		"--port", strconv.FormatUint(uint64(port), 10),
+		"--device", strconv.FormatUint(uint64(device), 10),
+		"--bandwidthgroup", group,
+	)
+}
+
+// SetBandwidthLimit changes the limit of the bandwidth group of the machine,
+// live if it is running. The limit is in megabytes per second, or in the
+// given unit, e.g. "500k", "20m" or "1g"; "0" disables the limit.
+func SetBandwidthLimit(vm, group, limit string) error {
+	if !reBandwidthLimit.MatchString(limit) {
+		return fmt.Errorf("invalid bandwidth limit: '%s'", limit)
+	}
+	return Manage().run("bandwidthctl", vm, "set", group, "--limit", limit)
+}
@@ -0,0 +1,51 @@
+package virtualbox
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestAssignBandwidthGroup(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		listOut := "Name: 'net', Type: Network, Limit: 20 Mbytes/sec (20971520 bytes/sec)\n" +
+			"Name: 'disk', Type: Disk, Limit: 100 Mbytes/sec (104857600 bytes/sec)\n"
+		vmInfoOut := ReadTestData("vboxmanage-showvminfo-poweroff.out")
+		running := strings.Replace(vmInfoOut, `VMState="poweroff"`, `VMState="running"`, 1)
+		gomock.InOrder(
+			ManageMock.EXPECT().runOut("bandwidthctl", VM, "list").Return(listOut, nil).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+			ManageMock.EXPECT().run("modifyvm", VM, "--nicbandwidthgroup1", "net").Return(nil).Times(1),
+			ManageMock.EXPECT().runOut("bandwidthctl", VM, "list").Return(listOut, nil).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+			ManageMock.EXPECT().run("storageattach", VM, "--storagectl", "SATA", "--port", "0", "--device", "0", "--bandwidthgroup", "disk").Return(nil).Times(1),
+			ManageMock.EXPECT().runOut("bandwidthctl", VM, "list").Return(listOut, nil).Times(2),
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(running, "", nil).Times(1),
+			ManageMock.EXPECT().run("bandwidthctl", VM, "set", "net", "--limit", "5m").Return(nil).Times(1),
+		)
+		if err := AssignNICBandwidthGroup(VM, 1, "net"); err != nil {
+			t.Fatal(err)
+		}
+		if err := AssignDiskBandwidthGroup(VM, "SATA", 0, 0, "disk"); err != nil {
+			t.Fatal(err)
+		}
+		if err := AssignNICBandwidthGroup(VM, 1, "disk"); err == nil || !strings.Contains(err.Error(), "Disk group") {
+			t.Fatalf("expected a group type error, got %v", err)
+		}
+		if err := AssignNICBandwidthGroup(VM, 1, "net"); !errors.Is(err, ErrMachineRunning) {
+			t.Fatalf("expected ErrMachineRunning, got %v", err)
+		}
+		if err := SetBandwidthLimit(VM, "net", "5m"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := SetBandwidthLimit(VM, "net", "5 MB"); err == nil {
+		t.Fatal("expected an error for an invalid limit")
+	}
+
+	Teardown()
+}
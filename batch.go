@@ -0,0 +1,80 @@
+package virtualbox
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// BatchRunner runs a list of operations concurrently, with at most
+// Concurrency of them in flight at once. Use it to parallelize commands such as
+// ImportOVF or CloneMachine across many machines without overwhelming the host
+// or tripping the VirtualBox internal locks.
+type BatchRunner struct {
+	Concurrency int // values below 1 run the operations one at a time
+	ops         []func() error
+}
+
+// NewBatchRunner creates a BatchRunner with the given concurrency limit.
+func NewBatchRunner(concurrency int) *BatchRunner {
+	return &BatchRunner{Concurrency: concurrency}
+}
+
+// Add queues an operation to be run.
+func (b *BatchRunner) Add(op func() error) {
+	b.ops = append(b.ops, op)
+}
+
+// BatchError aggregates the errors of the failed operations of a batch.
+type BatchError struct {
+	Errors map[int]error // keyed by the index of the operation, in Add order
+}
+
+func (e *BatchError) Error() string {
+	idx := make([]int, 0, len(e.Errors))
+	for i := range e.Errors {
+		idx = append(idx, i)
+	}
+	sort.Ints(idx)
+	msgs := make([]string, 0, len(idx))
+	for _, i := range idx {
+		msgs = append(msgs, fmt.Sprintf("operation %d: %v", i, e.Errors[i]))
+	}
+	return fmt.Sprintf("%d operation(s) failed: %s", len(e.Errors), strings.Join(msgs, "; "))
+}
+
+// Run runs every queued operation and waits for all of them to complete. It
+// returns a *BatchError when at least one of them failed.
+func (b *BatchRunner) Run() error {
+	limit := b.Concurrency
+	if limit < 1 {
+		limit = 1
+	}
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs = map[int]error{}
+		sem  = make(chan struct{}, limit)
+	)
+	for i, op := range b.ops {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, op func() error) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if err := op(); err != nil {
+				mu.Lock()
+				errs[i] = err
+				mu.Unlock()
+			}
+		}(i, op)
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return &BatchError{Errors: errs}
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package virtualbox
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBatchRunner(t *testing.T) {
+	var inflight, peak int32
+	b := NewBatchRunner(2)
+	for i := 0; i < 6; i++ {
+		i := i
+		b.Add(func() error {
+			n := atomic.AddInt32(&inflight, 1)
+			defer atomic.AddInt32(&inflight, -1)
+			for {
+				p := atomic.LoadInt32(&peak)
+				if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			if i%3 == 0 {
+				return errors.New("boom")
+			}
+			return nil
+		})
+	}
+
+	err := b.Run()
+	if peak > 2 {
+		t.Fatalf("expected at most 2 concurrent operations, got %d", peak)
+	}
+	berr, ok := err.(*BatchError)
+	if !ok {
+		t.Fatalf("expected a *BatchError, got %v", err)
+	}
+	if len(berr.Errors) != 2 || berr.Errors[0] == nil || berr.Errors[3] == nil {
+		t.Fatalf("unexpected errors: %v", berr)
+	}
+	t.Log(berr)
+}
@@ -0,0 +1,122 @@
+package virtualbox
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+)
+
+// BIOSBootMenu is when the BIOS offers its boot device menu (F12).
+type BIOSBootMenu string
+
+const (
+	// BootMenuDisabled never offers the boot menu.
+	BootMenuDisabled = BIOSBootMenu("disabled")
+	// BootMenuOnly offers the boot menu without telling the user about it.
+	BootMenuOnly = BIOSBootMenu("menuonly")
+	// BootMenuMessageAndMenu offers the boot menu and tells the user about it.
+	BootMenuMessageAndMenu = BIOSBootMenu("messageandmenu")
+)
+
+// APICMode is the APIC level the firmware reports to the guest.
+type APICMode string
+
+const (
+	// APICModeDisabled reports no APIC, for very old guests.
+	APICModeDisabled = APICMode("disabled")
+	// APICModeAPIC reports a local APIC, the VirtualBox default.
+	APICModeAPIC = APICMode("apic")
+	// APICModeX2APIC reports an x2APIC, needed for more than 255 CPUs.
+	APICModeX2APIC = APICMode("x2apic")
+)
+
+// BIOSConfig holds the boot logo and menu settings of a machine applied
+// together by ConfigureBIOS. Empty BootMenu and APIC are left unchanged.
+type BIOSConfig struct {
+	LogoFadeIn      bool
+	LogoFadeOut     bool
+	LogoDisplayTime time.Duration // how long the logo is shown, up to 65535ms
+	LogoImagePath   string        // uncompressed BMP, empty for the VirtualBox logo
+	BootMenu        BIOSBootMenu
+	APIC            APICMode
+}
+
+// ConfigureBIOS applies the BIOS boot logo and menu settings of the machine in
+// a single modifyvm call, e.g. to brand or hide the boot screen of an
+// appliance. The logo image must be a BMP file, the only format the VirtualBox
+// BIOS can display. The machine must not be running.
+func ConfigureBIOS(vm string, cfg BIOSConfig) error {
+	ms := int64(cfg.LogoDisplayTime / time.Millisecond)
+	if ms < 0 || ms > 65535 {
+		return fmt.Errorf("%w: logo display time %s is out of the 0-65535ms range", ErrInvalidArgument, cfg.LogoDisplayTime)
+	}
+	switch cfg.BootMenu {
+	case "", BootMenuDisabled, BootMenuOnly, BootMenuMessageAndMenu:
+	default:
+		return fmt.Errorf("%w: boot menu mode '%s'", ErrInvalidArgument, cfg.BootMenu)
+	}
+	switch cfg.APIC {
+	case "", APICModeDisabled, APICModeAPIC, APICModeX2APIC:
+	default:
+		return fmt.Errorf("%w: APIC mode '%s'", ErrInvalidArgument, cfg.APIC)
+	}
+	if cfg.LogoImagePath != "" {
+		if err := checkBMP(cfg.LogoImagePath); err != nil {
+			return err
+		}
+	}
+	if err := assertMutable(vm); err != nil {
+		return err
+	}
+	args := []string{"modifyvm", vm,
+		"--bioslogofadein", bool2string(cfg.LogoFadeIn),
+		"--bioslogofadeout", bool2string(cfg.LogoFadeOut),
+		"--bioslogodisplaytime", strconv.FormatInt(ms, 10),
+		"--bioslogoimagepath", cfg.LogoImagePath,
+	}
+	if cfg.BootMenu != "" {
+		args = append(args, "--biosbootmenu", string(cfg.BootMenu))
+	}
+	if cfg.APIC != "" {
+		args = append(args, "--biosapic", string(cfg.APIC))
+	}
+	return Manage().run(args...)
+}
+
+// checkBMP tells whether the file at path exists and starts with the BMP
+// signature.
+func checkBMP(path string) error {
+	f, err := os.Open(path) // #nosec
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	magic := make([]byte, 2)
+	if _, err := io.ReadFull(f, magic); err != nil || string(magic) != "BM" {
+		return fmt.Errorf("%w: boot logo '%s' is not a BMP image", ErrInvalidArgument, path)
+	}
+	return nil
+}
+
+// SetBIOSTimeOffset skews the guest clock from the host one by offset, which
+// may be negative, e.g. to test certificate expiry. VirtualBox keeps the
+// offset in milliseconds. The machine must not be running.
+func SetBIOSTimeOffset(vm string, offset time.Duration) error {
+	if err := assertMutable(vm); err != nil {
+		return err
+	}
+	ms := int64(offset / time.Millisecond)
+	return Manage().run("modifyvm", vm, "--biossystemtimeoffset", strconv.FormatInt(ms, 10))
+}
+
+// SetHPET toggles the High Precision Event Timer of the machine, which some
+// guest kernels and timing sensitive workloads need. The machine must not be
+// running. The current setting is the HPET bit of Machine.Flag.
+func SetHPET(vm string, on bool) error {
+	if err := assertMutable(vm); err != nil {
+		return err
+	}
+	return Manage().run("modifyvm", vm, "--hpet", bool2string(on))
+}
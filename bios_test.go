@@ -0,0 +1,132 @@
+package virtualbox
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestSetBIOSTimeOffset(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		vmInfoOut := ReadTestData("vboxmanage-showvminfo-poweroff.out")
+		skewed := strings.Replace(vmInfoOut, "biossystemtimeoffset=0", "biossystemtimeoffset=-86400000", 1)
+		gomock.InOrder(
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+			ManageMock.EXPECT().run("modifyvm", VM, "--biossystemtimeoffset", "-86400000").Return(nil).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(skewed, "", nil).Times(1),
+		)
+		if err := SetBIOSTimeOffset(VM, -24*time.Hour); err != nil {
+			t.Fatal(err)
+		}
+		m, err := GetMachine(VM)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m.BIOSTimeOffset != -24*time.Hour {
+			t.Fatalf("expected -24h, got %s", m.BIOSTimeOffset)
+		}
+	}
+
+	Teardown()
+}
+
+func TestSetHPET(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		vmInfoOut := ReadTestData("vboxmanage-showvminfo-poweroff.out")
+		withHPET := strings.Replace(vmInfoOut, `hpet="off"`, `hpet="on"`, 1)
+		running := strings.Replace(vmInfoOut, `VMState="poweroff"`, `VMState="running"`, 1)
+		gomock.InOrder(
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+			ManageMock.EXPECT().run("modifyvm", VM, "--hpet", "on").Return(nil).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(withHPET, "", nil).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(running, "", nil).Times(1),
+		)
+		if err := SetHPET(VM, true); err != nil {
+			t.Fatal(err)
+		}
+		m, err := GetMachine(VM)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m.Flag.Get(HPET) != "on" {
+			t.Fatal("expected the HPET flag to be set")
+		}
+		if err := SetHPET(VM, false); !errors.Is(err, ErrMachineRunning) {
+			t.Fatalf("expected ErrMachineRunning, got %v", err)
+		}
+	}
+
+	Teardown()
+}
+
+func TestConfigureBIOS(t *testing.T) {
+	Setup(t)
+
+	dir, err := ioutil.TempDir("", "go-virtualbox-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	logo := filepath.Join(dir, "logo.bmp")
+	if err := ioutil.WriteFile(logo, []byte("BM\x36\x00"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	png := filepath.Join(dir, "logo.png")
+	if err := ioutil.WriteFile(png, []byte("\x89PNG"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ConfigureBIOS(VM, BIOSConfig{LogoImagePath: png}); !errors.Is(err, ErrInvalidArgument) {
+		t.Fatalf("expected ErrInvalidArgument for a PNG logo, got %v", err)
+	}
+	if err := ConfigureBIOS(VM, BIOSConfig{LogoImagePath: filepath.Join(dir, "missing.bmp")}); !os.IsNotExist(err) {
+		t.Fatalf("expected a missing logo error, got %v", err)
+	}
+	if err := ConfigureBIOS(VM, BIOSConfig{LogoDisplayTime: 70 * time.Second}); !errors.Is(err, ErrInvalidArgument) {
+		t.Fatalf("expected ErrInvalidArgument for a too long display time, got %v", err)
+	}
+	if err := ConfigureBIOS(VM, BIOSConfig{BootMenu: "always"}); !errors.Is(err, ErrInvalidArgument) {
+		t.Fatalf("expected ErrInvalidArgument for an unknown boot menu mode, got %v", err)
+	}
+	if ManageMock != nil {
+		vmInfoOut := ReadTestData("vboxmanage-showvminfo-poweroff.out")
+		running := strings.Replace(vmInfoOut, `VMState="poweroff"`, `VMState="running"`, 1)
+		gomock.InOrder(
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+			ManageMock.EXPECT().run("modifyvm", VM,
+				"--bioslogofadein", "on",
+				"--bioslogofadeout", "off",
+				"--bioslogodisplaytime", "3000",
+				"--bioslogoimagepath", logo,
+				"--biosbootmenu", "disabled",
+				"--biosapic", "x2apic",
+			).Return(nil).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(running, "", nil).Times(1),
+		)
+		cfg := BIOSConfig{
+			LogoFadeIn:      true,
+			LogoDisplayTime: 3 * time.Second,
+			LogoImagePath:   logo,
+			BootMenu:        BootMenuDisabled,
+			APIC:            APICModeX2APIC,
+		}
+		if err := ConfigureBIOS(VM, cfg); err != nil {
+			t.Fatal(err)
+		}
+		if err := ConfigureBIOS(VM, cfg); !errors.Is(err, ErrMachineRunning) {
+			t.Fatalf("expected ErrMachineRunning, got %v", err)
+		}
+	}
+
+	Teardown()
+}
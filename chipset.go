@@ -0,0 +1,35 @@
+package virtualbox
+
+import "fmt"
+
+// ChipsetType is the emulated motherboard chipset of a machine.
+type ChipsetType string
+
+const (
+	// ChipsetPIIX3 is the default chipset, with up to 8 NICs in total and few PCI slots.
+	ChipsetPIIX3 = ChipsetType("piix3")
+	// ChipsetICH9 supports more PCI slots, e.g. for many NICs, and PCIe.
+	ChipsetICH9 = ChipsetType("ich9")
+)
+
+// SetChipset sets the emulated chipset of the machine, which must not be running.
+func SetChipset(vm string, chipset ChipsetType) error {
+	switch chipset {
+	case ChipsetPIIX3, ChipsetICH9:
+	default:
+		return fmt.Errorf("invalid chipset: '%s'", chipset)
+	}
+	if err := assertMutable(vm); err != nil {
+		return err
+	}
+	return Manage().run("modifyvm", vm, "--chipset", string(chipset))
+}
+
+// maxNICs returns how many network adapters a machine with the chipset can
+// have, as reported by 'VBoxManage list systemproperties'.
+func maxNICs(chipset ChipsetType) int {
+	if chipset == ChipsetICH9 {
+		return 36
+	}
+	return 8
+}
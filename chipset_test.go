@@ -0,0 +1,35 @@
+package virtualbox
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestSetChipset(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		vmInfoOut := ReadTestData("vboxmanage-showvminfo-poweroff.out")
+		gomock.InOrder(
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+			ManageMock.EXPECT().run("modifyvm", VM, "--chipset", "ich9").Return(nil).Times(1),
+		)
+		m, err := GetMachine(VM)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m.Chipset != ChipsetPIIX3 {
+			t.Fatalf("expected chipset piix3, got %s", m.Chipset)
+		}
+		if err := SetChipset(VM, ChipsetICH9); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := SetChipset(VM, "q35"); err == nil {
+		t.Fatal("expected an error for an invalid chipset")
+	}
+
+	Teardown()
+}
@@ -0,0 +1,111 @@
+package virtualbox
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// hostInfo reads the "Key: value" lines of 'list hostinfo'.
+func hostInfo() (map[string]string, error) {
+	out, err := Manage().runOut("list", "hostinfo")
+	if err != nil {
+		return nil, err
+	}
+	info := map[string]string{}
+	s := bufio.NewScanner(strings.NewReader(out))
+	for s.Scan() {
+		res := reColonLine.FindStringSubmatch(s.Text())
+		if res == nil {
+			continue
+		}
+		info[strings.TrimSpace(res[1])] = strings.TrimSpace(res[2])
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// hostCPUSupports tells whether the host processor supports the given
+// feature, as listed by 'list hostinfo', e.g. "long mode" or "PAE".
+func hostCPUSupports(feature string) (bool, error) {
+	info, err := hostInfo()
+	if err != nil {
+		return false, err
+	}
+	v, ok := info["Processor supports "+feature]
+	if !ok {
+		return false, fmt.Errorf("host processor support for %s is not reported", feature)
+	}
+	return v == "yes", nil
+}
+
+// SetCPUFeatures toggles the PAE, APIC, x2APIC and long mode (64-bit) CPU
+// features of the machine in a single modifyvm call. The machine must not be
+// running, x2APIC requires APIC, and long mode a 64-bit capable host.
+func SetCPUFeatures(vm string, pae, apic, x2apic, longmode bool) error {
+	if x2apic && !apic {
+		return fmt.Errorf("x2APIC requires APIC")
+	}
+	if longmode {
+		ok, err := hostCPUSupports("long mode")
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("the host processor does not support long mode")
+		}
+	}
+	if err := assertMutable(vm); err != nil {
+		return err
+	}
+	return Manage().run("modifyvm", vm,
+		"--pae", bool2string(pae),
+		"--apic", bool2string(apic),
+		"--x2apic", bool2string(x2apic),
+		"--longmode", bool2string(longmode),
+	)
+}
+
+// ErrIOAPICRequired holds the error message when several CPUs are asked for without an I/O APIC.
+var ErrIOAPICRequired = errors.New("more than one CPU requires the I/O APIC")
+
+// SetIOAPIC toggles the I/O APIC of the machine, which must not be running.
+// Machines with more than one CPU, and 64-bit Windows guests, need it: turning
+// it off while the machine has several CPUs fails with ErrIOAPICRequired.
+func SetIOAPIC(vm string, on bool) error {
+	props, err := machineProps(vm)
+	if err != nil {
+		return err
+	}
+	if !on && props["cpus"] != "" && props["cpus"] != "1" {
+		return fmt.Errorf("'%s' has %s CPUs: %w", vm, props["cpus"], ErrIOAPICRequired)
+	}
+	if err := assertMutable(vm); err != nil {
+		return err
+	}
+	return Manage().run("modifyvm", vm, "--ioapic", bool2string(on))
+}
+
+// SetCPUCount sets the number of virtual CPUs of the machine, which must not
+// be running. More than one CPU fails with ErrIOAPICRequired when the I/O
+// APIC is off, see SetIOAPIC.
+func SetCPUCount(vm string, n uint) error {
+	if n == 0 {
+		return fmt.Errorf("invalid CPU count: 0")
+	}
+	props, err := machineProps(vm)
+	if err != nil {
+		return err
+	}
+	if n > 1 && props["ioapic"] != "on" {
+		return fmt.Errorf("the I/O APIC of '%s' is off: %w", vm, ErrIOAPICRequired)
+	}
+	if err := assertMutable(vm); err != nil {
+		return err
+	}
+	return Manage().run("modifyvm", vm, "--cpus", strconv.FormatUint(uint64(n), 10))
+}
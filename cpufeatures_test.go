@@ -0,0 +1,68 @@
+package virtualbox
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestSetCPUFeatures(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		hostInfoOut := ReadTestData("vboxmanage-list-hostinfo-1.out")
+		vmInfoOut := ReadTestData("vboxmanage-showvminfo-poweroff.out")
+		gomock.InOrder(
+			ManageMock.EXPECT().runOut("list", "hostinfo").Return(hostInfoOut, nil).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+			ManageMock.EXPECT().run("modifyvm", VM, "--pae", "on", "--apic", "on", "--x2apic", "off", "--longmode", "on").Return(nil).Times(1),
+			ManageMock.EXPECT().runOut("list", "hostinfo").
+				Return(strings.Replace(hostInfoOut, "long mode: yes", "long mode: no", 1), nil).Times(1),
+		)
+		if err := SetCPUFeatures(VM, true, true, false, true); err != nil {
+			t.Fatal(err)
+		}
+		if err := SetCPUFeatures(VM, true, true, false, true); err == nil {
+			t.Fatal("expected an error for long mode on a 32-bit host")
+		}
+	}
+	if err := SetCPUFeatures(VM, false, false, true, false); err == nil {
+		t.Fatal("expected an error for x2APIC without APIC")
+	}
+
+	Teardown()
+}
+
+func TestSetIOAPIC(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		vmInfoOut := ReadTestData("vboxmanage-showvminfo-poweroff.out")
+		noIOAPIC := strings.Replace(vmInfoOut, `ioapic="on"`, `ioapic="off"`, 1)
+		twoCPUs := strings.Replace(vmInfoOut, "cpus=1", "cpus=2", 1)
+		gomock.InOrder(
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(noIOAPIC, "", nil).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(2),
+			ManageMock.EXPECT().run("modifyvm", VM, "--cpus", "2").Return(nil).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(twoCPUs, "", nil).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(2),
+			ManageMock.EXPECT().run("modifyvm", VM, "--ioapic", "off").Return(nil).Times(1),
+		)
+		if err := SetCPUCount(VM, 2); !errors.Is(err, ErrIOAPICRequired) {
+			t.Fatalf("expected ErrIOAPICRequired, got %v", err)
+		}
+		if err := SetCPUCount(VM, 2); err != nil {
+			t.Fatal(err)
+		}
+		if err := SetIOAPIC(VM, false); !errors.Is(err, ErrIOAPICRequired) {
+			t.Fatalf("expected ErrIOAPICRequired, got %v", err)
+		}
+		if err := SetIOAPIC(VM, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	Teardown()
+}
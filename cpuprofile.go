@@ -0,0 +1,65 @@
+package virtualbox
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+)
+
+// SetCPUProfile sets the CPU model presented to the guest, e.g. "Intel Core
+// i7-6700K", or "host" to pass the host CPU through. Profile names contain
+// spaces and are passed as a single argument. The machine must not be
+// running.
+func SetCPUProfile(vm, profile string) error {
+	if profile == "" {
+		return fmt.Errorf("cpu profile is empty")
+	}
+	if err := assertMutable(vm); err != nil {
+		return err
+	}
+	return Manage().run("modifyvm", vm, "--cpu-profile", profile)
+}
+
+// ListCPUProfiles lists the names of the CPU profiles known to VirtualBox.
+func ListCPUProfiles() ([]string, error) {
+	out, err := Manage().runOut("list", "cpu-profiles")
+	if err != nil {
+		return nil, err
+	}
+	var profiles []string
+	s := bufio.NewScanner(strings.NewReader(out))
+	for s.Scan() {
+		res := reColonLine.FindStringSubmatch(s.Text())
+		if res == nil {
+			continue
+		}
+		if strings.TrimSpace(res[1]) == "Name" {
+			profiles = append(profiles, strings.TrimSpace(res[2]))
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return profiles, nil
+}
+
+// RemoveAllCPUID removes all the CPUID leaf overrides of the machine, which
+// must not be running.
+func RemoveAllCPUID(vm string) error {
+	if err := assertMutable(vm); err != nil {
+		return err
+	}
+	// VirtualBox 7.0 still accepts the pre-7.0 option name.
+	return Manage().run("modifyvm", vm, "--cpuidremoveall")
+}
+
+// SetHostCPUPassthrough presents the host CPU to the guest as is, e.g. for
+// nested virtualization or benchmarks: it selects the "host" CPU profile and
+// removes all the CPUID overrides, in a single modifyvm call. The machine must
+// not be running.
+func SetHostCPUPassthrough(vm string) error {
+	if err := assertMutable(vm); err != nil {
+		return err
+	}
+	return Manage().run("modifyvm", vm, "--cpu-profile", "host", "--cpuidremoveall")
+}
@@ -0,0 +1,64 @@
+package virtualbox
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestCPUProfile(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		listOut := ReadTestData("vboxmanage-list-cpu-profiles-1.out")
+		vmInfoOut := ReadTestData("vboxmanage-showvminfo-poweroff.out")
+		gomock.InOrder(
+			ManageMock.EXPECT().runOut("list", "cpu-profiles").Return(listOut, nil).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+			ManageMock.EXPECT().run("modifyvm", VM, "--cpu-profile", "Intel Core i7-6700K").Return(nil).Times(1),
+		)
+		profiles, err := ListCPUProfiles()
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := []string{"Intel 8086", "Intel Core i7-6700K", "AMD Ryzen 7 1800X"}
+		if !reflect.DeepEqual(profiles, expected) {
+			t.Fatalf("expected %q, got %q", expected, profiles)
+		}
+		if err := SetCPUProfile(VM, profiles[1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	Teardown()
+}
+
+func TestSetHostCPUPassthrough(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		vmInfoOut := ReadTestData("vboxmanage-showvminfo-poweroff.out")
+		runningOut := strings.Replace(vmInfoOut, `VMState="poweroff"`, `VMState="running"`, 1)
+		gomock.InOrder(
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+			ManageMock.EXPECT().run("modifyvm", VM, "--cpu-profile", "host", "--cpuidremoveall").Return(nil).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+			ManageMock.EXPECT().run("modifyvm", VM, "--cpuidremoveall").Return(nil).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(runningOut, "", nil).Times(1),
+		)
+		if err := SetHostCPUPassthrough(VM); err != nil {
+			t.Fatal(err)
+		}
+		if err := RemoveAllCPUID(VM); err != nil {
+			t.Fatal(err)
+		}
+		if err := SetHostCPUPassthrough(VM); !errors.Is(err, ErrMachineRunning) {
+			t.Fatalf("expected ErrMachineRunning, got %v", err)
+		}
+	}
+
+	Teardown()
+}
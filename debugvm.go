@@ -0,0 +1,68 @@
+package virtualbox
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+var (
+	rePermissionDenied = regexp.MustCompile(`VERR_ACCESS_DENIED|(?i)permission denied`)
+)
+
+// debugVM runs a debugvm subcommand, mapping the common failures to errors.
+func debugVM(vm string, args ...string) (string, error) {
+	stdout, stderr, err := Manage().runOutErr(append([]string{"debugvm", vm}, args...)...)
+	if err != nil {
+		switch {
+		case reMachineNotFound.MatchString(stderr):
+			return "", ErrMachineNotExist
+		case reMachineNotRunning.MatchString(stderr):
+			return "", ErrMachineNotRunning
+		case rePermissionDenied.MatchString(stderr):
+			return "", fmt.Errorf("debugvm %s: %w", vm, os.ErrPermission)
+		}
+		return "", err
+	}
+	return stdout, nil
+}
+
+// DumpVMCore writes a core dump of the running machine to outPath, for
+// offline crash analysis. The dump is about the size of the guest memory.
+func DumpVMCore(vm, outPath string) error {
+	if err := checkWritableDir(filepath.Dir(outPath)); err != nil {
+		return err
+	}
+	_, err := debugVM(vm, "dumpvmcore", "--filename", outPath)
+	return err
+}
+
+// debugInfoItems lists the VMM info items DebugInfo accepts.
+var debugInfoItems = map[string]bool{
+	"help":      true,
+	"apic":      true,
+	"cpuid":     true,
+	"cpumguest": true,
+	"cpumhost":  true,
+	"cpumhyper": true,
+	"gdt":       true,
+	"idt":       true,
+	"ioapic":    true,
+	"ioport":    true,
+	"mmio":      true,
+	"mode":      true,
+	"pci":       true,
+	"pic":       true,
+	"pit":       true,
+	"timers":    true,
+}
+
+// DebugInfo returns the raw text of the given VMM info item of the running
+// machine, e.g. "pit" or "timers". Only a known set of items is accepted.
+func DebugInfo(vm, item string) (string, error) {
+	if !debugInfoItems[item] {
+		return "", fmt.Errorf("unsupported debugvm info item: '%s'", item)
+	}
+	return debugVM(vm, "info", item)
+}
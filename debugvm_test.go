@@ -0,0 +1,68 @@
+package virtualbox
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestDebugInfo(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		ManageMock.EXPECT().runOutErr("debugvm", VM, "info", "pit").Return("PIT (i8254) channel #0: ...\n", "", nil).Times(1)
+	}
+	out, err := DebugInfo(VM, "pit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log(out)
+
+	if ManageMock != nil {
+		stderr := "VBoxManage: error: Machine 'MyVM' is not currently running\n"
+		ManageMock.EXPECT().runOutErr("debugvm", VM, "info", "timers").Return("", stderr, errors.New("exit status 1")).Times(1)
+		if _, err := DebugInfo(VM, "timers"); err != ErrMachineNotRunning {
+			t.Fatalf("expected ErrMachineNotRunning, got %v", err)
+		}
+	}
+
+	if _, err := DebugInfo(VM, "pit; reboot"); err == nil {
+		t.Fatal("expected an error for an unknown info item")
+	}
+
+	Teardown()
+}
+
+func TestDumpVMCore(t *testing.T) {
+	Setup(t)
+
+	dir, err := ioutil.TempDir("", "go-virtualbox-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	core := filepath.Join(dir, "guest.core")
+
+	if err := DumpVMCore(VM, filepath.Join(dir, "missing", "guest.core")); err == nil {
+		t.Fatal("expected an error for a missing output folder")
+	}
+	if ManageMock != nil {
+		gomock.InOrder(
+			ManageMock.EXPECT().runOutErr("debugvm", VM, "dumpvmcore", "--filename", core).Return("", "", nil).Times(1),
+			ManageMock.EXPECT().runOutErr("debugvm", VM, "dumpvmcore", "--filename", core).
+				Return("", "VBoxManage: error: Machine 'go-virtualbox' is not currently running\n", errors.New("exit status 1")).Times(1),
+		)
+		if err := DumpVMCore(VM, core); err != nil {
+			t.Fatal(err)
+		}
+		if err := DumpVMCore(VM, core); err != ErrMachineNotRunning {
+			t.Fatalf("expected ErrMachineNotRunning, got %v", err)
+		}
+	}
+
+	Teardown()
+}
@@ -12,8 +12,11 @@ import (
 func MakeDiskImage(dest string, size uint, r io.Reader) error {
 	// Convert a raw image from stdin to the dest VDI image.
 	sizeBytes := int64(size) << 20 // usually won't fit in 32-bit int (max 2GB)
-	cmd := exec.Command(Manage().path(), "convertfromraw", "stdin", dest,
-		fmt.Sprintf("%d", sizeBytes), "--format", "VDI") // #nosec
+	args := []string{"convertfromraw", "stdin", dest, fmt.Sprintf("%d", sizeBytes), "--format", "VDI"}
+	if err := validateArgs(args); err != nil {
+		return err
+	}
+	cmd := exec.Command(Manage().path(), args...) // #nosec
 
 	if Verbose {
 		cmd.Stdout = os.Stdout
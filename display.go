@@ -0,0 +1,169 @@
+package virtualbox
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// DisplayAdvancedConfig holds display settings of a machine applied together
+// by ConfigureDisplayAdvanced.
+type DisplayAdvancedConfig struct {
+	Accelerate2DVideo   bool // 2D video acceleration, for Windows guests
+	VRDEMultiConnection bool // let several remote display clients connect at once
+}
+
+// ConfigureDisplayAdvanced applies the advanced display settings of the
+// machine in a single modifyvm call. The machine must not be running.
+func ConfigureDisplayAdvanced(vm string, cfg DisplayAdvancedConfig) error {
+	if err := assertMutable(vm); err != nil {
+		return err
+	}
+	return Manage().run("modifyvm", vm,
+		"--accelerate2dvideo", bool2string(cfg.Accelerate2DVideo),
+		"--vrdemulticon", bool2string(cfg.VRDEMultiConnection),
+	)
+}
+
+// Set2DVideoAccel toggles the 2D video acceleration of the machine, which must
+// not be running.
+func Set2DVideoAccel(vm string, on bool) error {
+	if err := assertMutable(vm); err != nil {
+		return err
+	}
+	return Manage().run("modifyvm", vm, "--accelerate2dvideo", bool2string(on))
+}
+
+// SetVRDEMultiConnection toggles whether several remote display clients can
+// connect to the machine at once. The machine must not be running.
+func SetVRDEMultiConnection(vm string, on bool) error {
+	if err := assertMutable(vm); err != nil {
+		return err
+	}
+	return Manage().run("modifyvm", vm, "--vrdemulticon", bool2string(on))
+}
+
+// monitorCount reads the number of virtual monitors from the machine props.
+func monitorCount(vm string, props map[string]string) (int, error) {
+	monitors, err := strconv.Atoi(props["monitorcount"])
+	if err != nil {
+		return 0, fmt.Errorf("monitor count of '%s' is not reported", vm)
+	}
+	return monitors, nil
+}
+
+// ScreenModeWidth, ScreenModeHeight and ScreenModeBPP are the mode of the
+// screens SetActiveScreens enables, which the guest may change afterwards.
+const (
+	ScreenModeWidth  = 1024
+	ScreenModeHeight = 768
+	ScreenModeBPP    = 32
+)
+
+// SetActiveScreens enables the first count virtual monitors of the running
+// machine, laid out left to right, and disables the others. count ranges from
+// 1 to the monitor count of the machine settings. Changing the screen layout
+// requires the Guest Additions.
+func SetActiveScreens(vm string, count int) error {
+	props, err := machineProps(vm)
+	if err != nil {
+		return err
+	}
+	monitors, err := monitorCount(vm, props)
+	if err != nil {
+		return err
+	}
+	if count < 1 || count > monitors {
+		return fmt.Errorf("invalid screen count %d, '%s' has %d monitors", count, vm, monitors)
+	}
+	switch MachineState(props["VMState"]) {
+	case Running, Paused:
+	default:
+		return ErrMachineNotRunning
+	}
+	if ready, _ := (GuestAdditionsProbe{}).Ready(vm); !ready {
+		return fmt.Errorf("cannot change the screens of '%s': %w", vm, ErrGuestAdditionsRequired)
+	}
+	// The primary screen cannot be disabled.
+	for n := 1; n < monitors; n++ {
+		args := []string{"controlvm", vm, "setscreenlayout", strconv.Itoa(n)}
+		if n < count {
+			args = append(args, "on", strconv.Itoa(n*ScreenModeWidth), "0",
+				strconv.Itoa(ScreenModeWidth), strconv.Itoa(ScreenModeHeight), strconv.Itoa(ScreenModeBPP))
+		} else {
+			args = append(args, "off")
+		}
+		if err := Manage().run(args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Screenshot saves the given screen of the running machine as a PNG file at
+// path. Screens are numbered from 0, the primary screen, to the monitor count
+// of the machine settings minus one.
+func Screenshot(vm string, screen int, path string) error {
+	props, err := machineProps(vm)
+	if err != nil {
+		return err
+	}
+	monitors, err := monitorCount(vm, props)
+	if err != nil {
+		return err
+	}
+	if screen < 0 || screen >= monitors {
+		return fmt.Errorf("invalid screen %d, '%s' has %d monitors", screen, vm, monitors)
+	}
+	switch MachineState(props["VMState"]) {
+	case Running, Paused:
+	default:
+		return ErrMachineNotRunning
+	}
+	args := []string{"controlvm", vm, "screenshotpng", path}
+	// Older releases only know about the primary screen.
+	if screen > 0 {
+		args = append(args, strconv.Itoa(screen))
+	}
+	return Manage().run(args...)
+}
+
+// SetVideoModeHint asks the guest to switch its primary screen to the given
+// mode, see SetScreenVideoModeHint.
+func SetVideoModeHint(vm string, width, height, bpp int) error {
+	return SetScreenVideoModeHint(vm, 0, width, height, bpp)
+}
+
+// SetScreenVideoModeHint asks the guest of the running machine to switch the
+// given screen, numbered from 0, to the given mode, e.g. 1920x1080 at 32 bits
+// per pixel. A zero width and height clear the hint, and a zero bpp keeps the
+// current depth. This is only a hint, which the Guest Additions must be
+// installed to honor, and which the guest may still ignore.
+func SetScreenVideoModeHint(vm string, screen, width, height, bpp int) error {
+	if width < 0 || height < 0 || bpp < 0 || (width == 0) != (height == 0) {
+		return fmt.Errorf("%w: invalid video mode %dx%dx%d", ErrInvalidArgument, width, height, bpp)
+	}
+	props, err := machineProps(vm)
+	if err != nil {
+		return err
+	}
+	monitors, err := monitorCount(vm, props)
+	if err != nil {
+		return err
+	}
+	if screen < 0 || screen >= monitors {
+		return fmt.Errorf("invalid screen %d, '%s' has %d monitors", screen, vm, monitors)
+	}
+	switch MachineState(props["VMState"]) {
+	case Running, Paused:
+	default:
+		return ErrMachineNotRunning
+	}
+	return Manage().run("controlvm", vm, "setvideomodehint",
+		strconv.Itoa(width), strconv.Itoa(height), strconv.Itoa(bpp), strconv.Itoa(screen))
+}
+
+// ClearVideoModeHint withdraws the video mode hint of the given screen, letting
+// the guest pick its mode again.
+func ClearVideoModeHint(vm string, screen int) error {
+	return SetScreenVideoModeHint(vm, screen, 0, 0, 0)
+}
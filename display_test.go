@@ -0,0 +1,120 @@
+package virtualbox
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestConfigureDisplayAdvanced(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		vmInfoOut := ReadTestData("vboxmanage-showvminfo-poweroff.out")
+		runningOut := strings.Replace(vmInfoOut, `VMState="poweroff"`, `VMState="running"`, 1)
+		gomock.InOrder(
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+			ManageMock.EXPECT().run("modifyvm", VM, "--accelerate2dvideo", "off", "--vrdemulticon", "on").Return(nil).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(runningOut, "", nil).Times(1),
+		)
+		if err := ConfigureDisplayAdvanced(VM, DisplayAdvancedConfig{VRDEMultiConnection: true}); err != nil {
+			t.Fatal(err)
+		}
+		if err := SetVRDEMultiConnection(VM, true); !errors.Is(err, ErrMachineRunning) {
+			t.Fatalf("expected ErrMachineRunning, got %v", err)
+		}
+	}
+
+	Teardown()
+}
+
+func TestSetActiveScreens(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		vmInfoOut := strings.Replace(ReadTestData("vboxmanage-showvminfo-1.out"), `VMState="saved"`, `VMState="running"`, 1)
+		threeMonitors := strings.Replace(vmInfoOut, "monitorcount=1", "monitorcount=3", 1)
+		ManageMock.EXPECT().isGuest().Return(false).AnyTimes()
+		gomock.InOrder(
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(threeMonitors, "", nil).Times(1),
+			ManageMock.EXPECT().runOut("guestproperty", "get", VM, "/VirtualBox/GuestAdd/Version").Return("Value: 6.1.16", nil).Times(1),
+			ManageMock.EXPECT().run("controlvm", VM, "setscreenlayout", "1", "on", "1024", "0", "1024", "768", "32").Return(nil).Times(1),
+			ManageMock.EXPECT().run("controlvm", VM, "setscreenlayout", "2", "off").Return(nil).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(threeMonitors, "", nil).Times(1),
+			ManageMock.EXPECT().runOut("guestproperty", "get", VM, "/VirtualBox/GuestAdd/Version").Return("No value set!", nil).Times(1),
+		)
+		if err := SetActiveScreens(VM, 2); err != nil {
+			t.Fatal(err)
+		}
+		if err := SetActiveScreens(VM, 2); err == nil {
+			t.Fatal("expected an error for more screens than monitors")
+		}
+		if err := SetActiveScreens(VM, 3); !errors.Is(err, ErrGuestAdditionsRequired) {
+			t.Fatalf("expected ErrGuestAdditionsRequired, got %v", err)
+		}
+	}
+
+	Teardown()
+}
+
+func TestScreenshot(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		vmInfoOut := strings.Replace(ReadTestData("vboxmanage-showvminfo-1.out"), `VMState="saved"`, `VMState="running"`, 1)
+		twoMonitors := strings.Replace(vmInfoOut, "monitorcount=1", "monitorcount=2", 1)
+		gomock.InOrder(
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+			ManageMock.EXPECT().run("controlvm", VM, "screenshotpng", "primary.png").Return(nil).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(twoMonitors, "", nil).Times(1),
+			ManageMock.EXPECT().run("controlvm", VM, "screenshotpng", "second.png", "1").Return(nil).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(twoMonitors, "", nil).Times(1),
+		)
+		if err := Screenshot(VM, 0, "primary.png"); err != nil {
+			t.Fatal(err)
+		}
+		if err := Screenshot(VM, 1, "second.png"); err != nil {
+			t.Fatal(err)
+		}
+		if err := Screenshot(VM, 2, "third.png"); err == nil || !strings.Contains(err.Error(), "has 2 monitors") {
+			t.Fatalf("expected an error for a missing screen, got %v", err)
+		}
+	}
+
+	Teardown()
+}
+
+func TestSetScreenVideoModeHint(t *testing.T) {
+	Setup(t)
+
+	if err := SetVideoModeHint(VM, 1920, 0, 32); !errors.Is(err, ErrInvalidArgument) {
+		t.Fatalf("expected ErrInvalidArgument, got %v", err)
+	}
+	if ManageMock != nil {
+		vmInfoOut := strings.Replace(ReadTestData("vboxmanage-showvminfo-1.out"), `VMState="saved"`, `VMState="running"`, 1)
+		twoMonitors := strings.Replace(vmInfoOut, "monitorcount=1", "monitorcount=2", 1)
+		ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(twoMonitors, "", nil).Times(4)
+		gomock.InOrder(
+			ManageMock.EXPECT().run("controlvm", VM, "setvideomodehint", "1920", "1080", "32", "0").Return(nil).Times(1),
+			ManageMock.EXPECT().run("controlvm", VM, "setvideomodehint", "1280", "1024", "0", "1").Return(nil).Times(1),
+			ManageMock.EXPECT().run("controlvm", VM, "setvideomodehint", "0", "0", "0", "1").Return(nil).Times(1),
+		)
+		if err := SetVideoModeHint(VM, 1920, 1080, 32); err != nil {
+			t.Fatal(err)
+		}
+		if err := SetScreenVideoModeHint(VM, 1, 1280, 1024, 0); err != nil {
+			t.Fatal(err)
+		}
+		if err := ClearVideoModeHint(VM, 1); err != nil {
+			t.Fatal(err)
+		}
+		if err := ClearVideoModeHint(VM, 2); err == nil {
+			t.Fatal("expected an error for a missing screen")
+		}
+	}
+
+	Teardown()
+}
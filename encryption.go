@@ -0,0 +1,21 @@
+package virtualbox
+
+// AddEncryptionPassword supplies the password of the disks encrypted with the
+// given password id to the running machine. The password is handed over in a
+// private temporary file, never on the command line. With removeOnSuspend, the
+// password is forgotten when the machine is suspended.
+func AddEncryptionPassword(vm, id, password string, removeOnSuspend bool) error {
+	removeOpt := "no"
+	if removeOnSuspend {
+		removeOpt = "yes"
+	}
+	return withSecretFile(password, func(path string) error {
+		return Manage().run("controlvm", vm, "addencpassword", id, path, "--removeonsuspend", removeOpt)
+	})
+}
+
+// RemoveEncryptionPassword makes the running machine forget the password with
+// the given id.
+func RemoveEncryptionPassword(vm, id string) error {
+	return Manage().run("controlvm", vm, "removeencpassword", id)
+}
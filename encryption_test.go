@@ -0,0 +1,48 @@
+package virtualbox
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestAddEncryptionPassword(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		const password = "s3cr3t pass"
+		var passwordFile string
+		gomock.InOrder(
+			ManageMock.EXPECT().run("controlvm", VM, "addencpassword", "disk-key", gomock.Any(), "--removeonsuspend", "yes").
+				DoAndReturn(func(args ...string) error {
+					if strings.Contains(strings.Join(args, " "), password) {
+						t.Fatalf("password passed on the command line: %q", args)
+					}
+					passwordFile = args[4]
+					b, err := ioutil.ReadFile(passwordFile)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if string(b) != password {
+						t.Fatalf("expected the password in %s, got %q", passwordFile, b)
+					}
+					return nil
+				}).Times(1),
+			ManageMock.EXPECT().run("controlvm", VM, "removeencpassword", "disk-key").Return(nil).Times(1),
+		)
+		if err := AddEncryptionPassword(VM, "disk-key", password, true); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := os.Stat(passwordFile); !os.IsNotExist(err) {
+			t.Fatalf("password file %s was not removed", passwordFile)
+		}
+		if err := RemoveEncryptionPassword(VM, "disk-key"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	Teardown()
+}
@@ -0,0 +1,147 @@
+package virtualbox
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrMachineDrift holds the error message when an existing machine does not match its spec.
+	ErrMachineDrift = errors.New("machine does not match its spec")
+)
+
+// CreateSpec describes the machine EnsureMachine creates. Zero fields are
+// left to the VirtualBox defaults and not checked for drift.
+type CreateSpec struct {
+	Name       string
+	BaseFolder string // parent of the machine folder, default if empty
+	OSType     string // one of ListOSTypes, e.g. "Ubuntu_64"
+	CPUs       uint
+	Memory     uint // main memory (in MB)
+	VRAM       uint // video memory (in MB)
+}
+
+// EnsureOption customizes EnsureMachine.
+type EnsureOption func(*ensureConfig)
+
+type ensureConfig struct {
+	validate bool
+	recreate bool
+}
+
+// EnsureValidate makes EnsureMachine fail with ErrMachineDrift when the
+// existing machine does not match the spec.
+func EnsureValidate() EnsureOption {
+	return func(cfg *ensureConfig) {
+		cfg.validate = true
+	}
+}
+
+// EnsureRecreate makes EnsureMachine delete and create again the existing
+// machine, disks included, when it does not match the spec. The machine must
+// not be running.
+func EnsureRecreate() EnsureOption {
+	return func(cfg *ensureConfig) {
+		cfg.recreate = true
+	}
+}
+
+// EnsureMachine returns the machine named after the spec, creating it when
+// there is none, so that provisioning can be run again without failing with
+// ErrMachineExist. A group qualified name, e.g. "/prod/web", is looked up by
+// its base name and created in that group.
+func EnsureMachine(spec CreateSpec, opts ...EnsureOption) (*Machine, error) {
+	var cfg ensureConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	_, name := splitMachineName(spec.Name)
+	m, err := GetMachine(name)
+	if err == ErrMachineNotExist {
+		return createFromSpec(spec)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !cfg.validate && !cfg.recreate {
+		return m, nil
+	}
+	drift, err := specDrift(spec, m)
+	if err != nil {
+		return nil, err
+	}
+	if len(drift) == 0 {
+		return m, nil
+	}
+	if !cfg.recreate {
+		return nil, fmt.Errorf("%w: '%s' differs on %s", ErrMachineDrift, spec.Name, strings.Join(drift, ", "))
+	}
+	if err := assertMutable(name); err != nil {
+		return nil, err
+	}
+	if err := m.Delete(); err != nil {
+		return nil, err
+	}
+	return createFromSpec(spec)
+}
+
+func createFromSpec(spec CreateSpec) (*Machine, error) {
+	if _, err := CreateMachine(spec.Name, spec.BaseFolder); err != nil {
+		return nil, err
+	}
+	_, name := splitMachineName(spec.Name)
+	args := []string{"modifyvm", name}
+	if spec.OSType != "" {
+		args = append(args, "--ostype", spec.OSType)
+	}
+	if spec.CPUs != 0 {
+		args = append(args, "--cpus", strconv.FormatUint(uint64(spec.CPUs), 10))
+	}
+	if spec.Memory != 0 {
+		args = append(args, "--memory", strconv.FormatUint(uint64(spec.Memory), 10))
+	}
+	if spec.VRAM != 0 {
+		args = append(args, "--vram", strconv.FormatUint(uint64(spec.VRAM), 10))
+	}
+	if len(args) > 2 {
+		if err := Manage().run(args...); err != nil {
+			return nil, err
+		}
+	}
+	return GetMachine(name)
+}
+
+// specDrift lists the settings of the machine which differ from the spec.
+func specDrift(spec CreateSpec, m *Machine) ([]string, error) {
+	var drift []string
+	group, name := splitMachineName(spec.Name)
+	if spec.BaseFolder != "" && filepath.Clean(m.BaseFolder) != filepath.Join(spec.BaseFolder, group, name) {
+		drift = append(drift, "base folder")
+	}
+	if spec.CPUs != 0 && m.CPUs != spec.CPUs {
+		drift = append(drift, "cpus")
+	}
+	if spec.Memory != 0 && m.Memory != spec.Memory {
+		drift = append(drift, "memory")
+	}
+	if spec.VRAM != 0 && m.VRAM != spec.VRAM {
+		drift = append(drift, "vram")
+	}
+	if spec.OSType != "" {
+		// showvminfo only reports the description of the OS type.
+		types, err := ListOSTypes()
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range types {
+			if t.ID == spec.OSType && t.Description != m.OSTypeDescription {
+				drift = append(drift, "os type")
+			}
+		}
+	}
+	return drift, nil
+}
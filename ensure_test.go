@@ -0,0 +1,70 @@
+package virtualbox
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestEnsureMachine(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		vmInfoOut := ReadTestData("vboxmanage-showvminfo-1.out")
+		listOut := ReadTestData("vboxmanage-list-ostypes-1.out")
+		notFound := "VBoxManage: error: Could not find a registered machine named 'go-virtualbox'\n"
+		spec := CreateSpec{Name: "go-virtualbox", OSType: "Ubuntu_64", Memory: 1024}
+
+		ManageMock.EXPECT().runOutErr("showvminfo", "go-virtualbox", "--machinereadable").Return(vmInfoOut, "", nil).Times(1)
+		m, err := EnsureMachine(spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m.UUID != "37f5d336-bf07-48dd-947c-37e6a56420a7" {
+			t.Fatalf("unexpected machine: %+v", m)
+		}
+
+		gomock.InOrder(
+			ManageMock.EXPECT().runOutErr("showvminfo", "go-virtualbox", "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+			ManageMock.EXPECT().runOut("list", "ostypes").Return(listOut, nil).Times(1),
+		)
+		spec.Memory = 2048
+		if _, err := EnsureMachine(spec, EnsureValidate()); !errors.Is(err, ErrMachineDrift) {
+			t.Fatalf("expected ErrMachineDrift, got %v", err)
+		}
+
+		gomock.InOrder(
+			ManageMock.EXPECT().runOutErr("showvminfo", "go-virtualbox", "--machinereadable").Return("", notFound, errors.New("exit status 1")).Times(1),
+			ManageMock.EXPECT().runOut("list", "vms").Return("", nil).Times(1),
+			ManageMock.EXPECT().run("createvm", "--name", "go-virtualbox", "--register").Return(nil).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", "go-virtualbox", "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+			ManageMock.EXPECT().run("modifyvm", "go-virtualbox", "--ostype", "Ubuntu_64", "--memory", "2048").Return(nil).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", "go-virtualbox", "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+		)
+		if _, err := EnsureMachine(spec); err != nil {
+			t.Fatal(err)
+		}
+
+		spec.Name = "/prod/go-virtualbox"
+		gomock.InOrder(
+			ManageMock.EXPECT().runOutErr("showvminfo", "go-virtualbox", "--machinereadable").Return("", notFound, errors.New("exit status 1")).Times(1),
+			ManageMock.EXPECT().runOut("list", "vms").Return("", nil).Times(1),
+			ManageMock.EXPECT().run("createvm", "--name", "go-virtualbox", "--groups", "/prod", "--register").Return(nil).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", "go-virtualbox", "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+			ManageMock.EXPECT().run("modifyvm", "go-virtualbox", "--ostype", "Ubuntu_64", "--memory", "2048").Return(nil).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", "go-virtualbox", "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+		)
+		if _, err := EnsureMachine(spec); err != nil {
+			t.Fatal(err)
+		}
+
+		// The next run finds the machine created in its group.
+		ManageMock.EXPECT().runOutErr("showvminfo", "go-virtualbox", "--machinereadable").Return(vmInfoOut, "", nil).Times(1)
+		if _, err := EnsureMachine(spec); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	Teardown()
+}
@@ -0,0 +1,148 @@
+package virtualbox
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// MACPolicy tells which NIC MAC addresses an export keeps.
+type MACPolicy string
+
+const (
+	// ExportAllMACs keeps all the MAC addresses.
+	ExportAllMACs = MACPolicy("keepallmacs")
+	// ExportNoMACs strips all the MAC addresses, so that each import gets new ones.
+	ExportNoMACs = MACPolicy("nomacs")
+	// ExportNoMACsButNAT strips the MAC addresses of all but the NAT NICs.
+	ExportNoMACsButNAT = MACPolicy("nomacsbutnat")
+)
+
+// ExportFormat is the layout of an exported appliance.
+type ExportFormat string
+
+const (
+	// ExportFormatOVA writes a single .ova archive.
+	ExportFormatOVA = ExportFormat("ova")
+	// ExportFormatOVF writes an .ovf descriptor and the disks next to it.
+	ExportFormatOVF = ExportFormat("ovf")
+)
+
+// ExportOption customizes ExportOVA.
+type ExportOption func(*exportConfig)
+
+type exportConfig struct {
+	snapshot string
+	macs     MACPolicy
+	format   ExportFormat
+	isos     bool
+}
+
+// ExportFromSnapshot exports the state captured by the given snapshot rather
+// than the current state of the machine.
+func ExportFromSnapshot(snapshot string) ExportOption {
+	return func(cfg *exportConfig) {
+		cfg.snapshot = snapshot
+	}
+}
+
+// ExportMACs sets which MAC addresses the export keeps, ExportNoMACs by default.
+func ExportMACs(policy MACPolicy) ExportOption {
+	return func(cfg *exportConfig) {
+		cfg.macs = policy
+	}
+}
+
+// ExportAs sets the layout of the export. By default, it is derived from the
+// extension of the path.
+func ExportAs(format ExportFormat) ExportOption {
+	return func(cfg *exportConfig) {
+		cfg.format = format
+	}
+}
+
+// ExportWithISOs includes the ISO images attached to the machine.
+func ExportWithISOs() ExportOption {
+	return func(cfg *exportConfig) {
+		cfg.isos = true
+	}
+}
+
+// validate rejects invalid or incompatible export options.
+func (cfg exportConfig) validate(path string) error {
+	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(path), "."))
+	switch cfg.format {
+	case "":
+		if ext != string(ExportFormatOVA) && ext != string(ExportFormatOVF) {
+			return fmt.Errorf("cannot tell the export format of '%s', expecting .ova or .ovf", path)
+		}
+	case ExportFormatOVA, ExportFormatOVF:
+		// VBoxManage picks the format from the extension only.
+		if ext != string(cfg.format) {
+			return fmt.Errorf("export format %s does not match the path '%s'", cfg.format, path)
+		}
+	default:
+		return fmt.Errorf("invalid export format: '%s'", cfg.format)
+	}
+	switch cfg.macs {
+	case "", ExportNoMACs, ExportNoMACsButNAT, ExportAllMACs:
+	default:
+		return fmt.Errorf("invalid MAC policy: '%s'", cfg.macs)
+	}
+	return nil
+}
+
+// args returns the export arguments matching the configuration.
+func (cfg exportConfig) args(vm, path string) []string {
+	var options []string
+	switch cfg.macs {
+	case "":
+		options = append(options, string(ExportNoMACs))
+	case ExportNoMACs, ExportNoMACsButNAT:
+		options = append(options, string(cfg.macs))
+	}
+	if cfg.isos {
+		options = append(options, "iso")
+	}
+
+	args := []string{"export", vm, "--output", path}
+	if len(options) > 0 {
+		args = append(args, "--options", strings.Join(options, ","))
+	}
+	return args
+}
+
+// ExportOVA exports the machine as an appliance to the given path. MAC
+// addresses are stripped unless asked otherwise, so that the appliance can be
+// imported several times on the same network.
+func ExportOVA(vm, path string, opts ...ExportOption) error {
+	var cfg exportConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if err := cfg.validate(path); err != nil {
+		return err
+	}
+
+	if cfg.snapshot == "" {
+		return Manage().run(cfg.args(vm, path)...)
+	}
+
+	// VBoxManage cannot export a snapshot directly: clone the snapshot into a
+	// temporary machine, export that one, and always get rid of it.
+	tmp := fmt.Sprintf("%s-export-%d", vm, time.Now().UnixNano())
+	err := Manage().run("clonevm", vm, "--snapshot", cfg.snapshot, "--name", tmp, "--register")
+	if err != nil {
+		// A clone failing partway may already be registered.
+		if _, perr := machineProps(tmp); perr == ErrMachineNotExist {
+			return err
+		}
+	} else {
+		err = Manage().run(cfg.args(tmp, path)...)
+	}
+	if uerr := Manage().run("unregistervm", tmp, "--delete"); uerr != nil && err == nil {
+		err = fmt.Errorf("failed removing temporary machine '%s': %w", tmp, uerr)
+	}
+	return err
+}
@@ -0,0 +1,91 @@
+package virtualbox
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestExportOVAFromSnapshot(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		var tmp string
+		gomock.InOrder(
+			ManageMock.EXPECT().run("clonevm", VM, "--snapshot", "golden", "--name", gomock.Any(), "--register").
+				DoAndReturn(func(args ...string) error { tmp = args[5]; return nil }).Times(1),
+			ManageMock.EXPECT().run("export", gomock.Any(), "--output", "golden.ova", "--options", "nomacs").Return(errors.New("disk full")).Times(1),
+			ManageMock.EXPECT().run("unregistervm", gomock.Any(), "--delete").
+				DoAndReturn(func(args ...string) error {
+					if args[1] != tmp || !strings.HasPrefix(tmp, VM+"-export-") {
+						t.Fatalf("unexpected temporary machine '%s'", args[1])
+					}
+					return nil
+				}).Times(1),
+		)
+		if err := ExportOVA(VM, "golden.ova", ExportFromSnapshot("golden")); err == nil || err.Error() != "disk full" {
+			t.Fatalf("expected the export error, got %v", err)
+		}
+	}
+
+	Teardown()
+}
+
+func TestExportOVAFromSnapshotCloneFailure(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		var tmp string
+		cloneErr := errors.New("exit status 1")
+		notFound := "VBoxManage: error: Could not find a registered machine named '-export-'\n"
+		gomock.InOrder(
+			ManageMock.EXPECT().run("clonevm", VM, "--snapshot", "golden", "--name", gomock.Any(), "--register").
+				DoAndReturn(func(args ...string) error { tmp = args[5]; return cloneErr }).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", gomock.Any(), "--machinereadable").Return(ReadTestData("vboxmanage-showvminfo-1.out"), "", nil).Times(1),
+			ManageMock.EXPECT().run("unregistervm", gomock.Any(), "--delete").
+				DoAndReturn(func(args ...string) error {
+					if args[1] != tmp {
+						t.Fatalf("unexpected temporary machine '%s'", args[1])
+					}
+					return nil
+				}).Times(1),
+			ManageMock.EXPECT().run("clonevm", VM, "--snapshot", "golden", "--name", gomock.Any(), "--register").Return(cloneErr).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", gomock.Any(), "--machinereadable").Return("", notFound, errors.New("exit status 1")).Times(1),
+		)
+		// The partial clone is registered and must be removed.
+		if err := ExportOVA(VM, "golden.ova", ExportFromSnapshot("golden")); err != cloneErr {
+			t.Fatalf("expected the clone error, got %v", err)
+		}
+		// No clone was registered, nothing to remove.
+		if err := ExportOVA(VM, "golden.ova", ExportFromSnapshot("golden")); err != cloneErr {
+			t.Fatalf("expected the clone error, got %v", err)
+		}
+	}
+
+	Teardown()
+}
+
+func TestExportOVAOptions(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		ManageMock.EXPECT().run("export", VM, "--output", "golden.ovf", "--options", "nomacsbutnat,iso").Return(nil).Times(1)
+		ManageMock.EXPECT().run("export", VM, "--output", "golden.ova").Return(nil).Times(1)
+		if err := ExportOVA(VM, "golden.ovf", ExportAs(ExportFormatOVF), ExportMACs(ExportNoMACsButNAT), ExportWithISOs()); err != nil {
+			t.Fatal(err)
+		}
+		if err := ExportOVA(VM, "golden.ova", ExportMACs(ExportAllMACs)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := ExportOVA(VM, "golden.ova", ExportAs(ExportFormatOVF)); err == nil {
+		t.Fatal("expected an error for an ovf export to an .ova path")
+	}
+	if err := ExportOVA(VM, "golden.img"); err == nil {
+		t.Fatal("expected an error for an unknown export format")
+	}
+
+	Teardown()
+}
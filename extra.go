@@ -1,5 +1,7 @@
 package virtualbox
 
+import "fmt"
+
 // SetExtra sets extra data. Name could be "global"|<uuid>|<vmname>
 func SetExtra(name, key, val string) error {
 	return Manage().run("setextradata", name, key, val)
@@ -9,3 +11,35 @@ func SetExtra(name, key, val string) error {
 func DelExtra(name, key string) error {
 	return Manage().run("setextradata", name, key)
 }
+
+// ManagedByKey is the extra data key MarkManaged records the owner under.
+const ManagedByKey = "go-virtualbox/managed-by"
+
+// MarkManaged records that the machine is managed by the given owner, e.g. the
+// name of the tool which created it, so that cleanups can leave alone the
+// machines they do not own. It replaces any previous owner.
+func MarkManaged(vm, owner string) error {
+	if owner == "" {
+		return fmt.Errorf("%w: owner is empty", ErrInvalidArgument)
+	}
+	return SetExtra(vm, ManagedByKey, owner)
+}
+
+// UnmarkManaged removes the owner recorded by MarkManaged, e.g. to hand the
+// machine over to a human.
+func UnmarkManaged(vm string) error {
+	return DelExtra(vm, ManagedByKey)
+}
+
+// IsManaged tells whether the machine was marked with MarkManaged, and by
+// which owner.
+func IsManaged(vm string) (bool, string, error) {
+	owner, err := (&Machine{Name: vm}).GetExtraData(ManagedByKey)
+	if err != nil {
+		return false, "", err
+	}
+	if owner == nil || *owner == "" {
+		return false, "", nil
+	}
+	return true, *owner, nil
+}
@@ -0,0 +1,38 @@
+package virtualbox
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestManaged(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		gomock.InOrder(
+			ManageMock.EXPECT().run("setextradata", VM, ManagedByKey, "fleetd").Return(nil).Times(1),
+			ManageMock.EXPECT().runOut("getextradata", VM, ManagedByKey).Return("Value: fleetd\n", nil).Times(1),
+			ManageMock.EXPECT().runOut("getextradata", VM, ManagedByKey).Return("No value set!\n", nil).Times(1),
+		)
+		if err := MarkManaged(VM, "fleetd"); err != nil {
+			t.Fatal(err)
+		}
+		managed, owner, err := IsManaged(VM)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !managed || owner != "fleetd" {
+			t.Fatalf("expected managed by fleetd, got %v '%s'", managed, owner)
+		}
+		if managed, _, err := IsManaged(VM); err != nil || managed {
+			t.Fatalf("expected unmanaged, got %v %v", managed, err)
+		}
+	}
+	if err := MarkManaged(VM, ""); !errors.Is(err, ErrInvalidArgument) {
+		t.Fatalf("expected ErrInvalidArgument, got %v", err)
+	}
+
+	Teardown()
+}
@@ -0,0 +1,126 @@
+package virtualbox
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// FanOutOption customizes FanOut.
+type FanOutOption func(*fanOutConfig)
+
+type fanOutConfig struct {
+	concurrency int
+}
+
+// FanOutConcurrency clones up to n machines at once through a BatchRunner,
+// rather than one after the other.
+func FanOutConcurrency(n int) FanOutOption {
+	return func(cfg *fanOutConfig) {
+		cfg.concurrency = n
+	}
+}
+
+// FanOut clones the base machine into count machines named <namePrefix>-1 to
+// <namePrefix>-<count>, and registers them. Linked clones share the disks of
+// the current snapshot of the base machine, which is taken first if the
+// machine has none, and only store their own changes. When any clone fails,
+// all the clones created so far are unregistered and deleted, along with the
+// snapshot FanOut took. A group qualified namePrefix, e.g. "/lab/web", puts
+// the clones in that group.
+func FanOut(baseVM string, count int, namePrefix string, linked bool, opts ...FanOutOption) ([]*Machine, error) {
+	cfg := fanOutConfig{concurrency: 1}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if count < 1 {
+		return nil, fmt.Errorf("%w: invalid clone count %d", ErrInvalidArgument, count)
+	}
+	names := make([]string, count)
+	for i := range names {
+		if err := ValidateMachineName(fmt.Sprintf("%s-%d", namePrefix, i+1)); err != nil {
+			return nil, err
+		}
+	}
+	group, namePrefix := splitMachineName(namePrefix)
+	for i := range names {
+		names[i] = fmt.Sprintf("%s-%d", namePrefix, i+1)
+	}
+
+	var snapshot, taken string
+	if linked {
+		snapshots, err := ListSnapshots(baseVM)
+		if err != nil {
+			return nil, err
+		}
+		for _, snap := range snapshots {
+			if snap.Current {
+				snapshot = snap.UUID
+			}
+		}
+		if snapshot == "" {
+			taken = namePrefix + "-base"
+			if err := TakeSnapshot(baseVM, taken, "Base of the linked clones "+namePrefix+"-N"); err != nil {
+				return nil, err
+			}
+			snapshot = taken
+		}
+	}
+
+	var (
+		mu      sync.Mutex
+		created []string
+	)
+	b := NewBatchRunner(cfg.concurrency)
+	for _, name := range names {
+		name := name
+		b.Add(func() error {
+			args := []string{"clonevm", baseVM, "--name", name, "--register"}
+			if group != "" {
+				args = append(args, "--groups", group)
+			}
+			if linked {
+				args = append(args, "--snapshot", snapshot, "--options", "link")
+			}
+			if err := Manage().run(args...); err != nil {
+				return fmt.Errorf("cloning '%s': %w", name, err)
+			}
+			mu.Lock()
+			created = append(created, name)
+			mu.Unlock()
+			return nil
+		})
+	}
+	err := b.Run()
+
+	var ms []*Machine
+	if err == nil {
+		for _, name := range names {
+			var m *Machine
+			if m, err = GetMachine(name); err != nil {
+				break
+			}
+			ms = append(ms, m)
+		}
+	}
+	if err == nil {
+		return ms, nil
+	}
+
+	// Never leave a partial lab behind.
+	var failed []string
+	for _, name := range created {
+		if uerr := Manage().run("unregistervm", name, "--delete"); uerr != nil {
+			failed = append(failed, name)
+		}
+	}
+	if taken != "" && len(failed) == 0 {
+		if derr := DeleteSnapshot(baseVM, taken); derr != nil {
+			failed = append(failed, "snapshot "+taken)
+		}
+	}
+	if len(failed) > 0 {
+		return nil, fmt.Errorf("%w (rollback failed for %s)", err, strings.Join(failed, ", "))
+	}
+	return nil, err
+}
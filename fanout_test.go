@@ -0,0 +1,47 @@
+package virtualbox
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestFanOut(t *testing.T) {
+	Setup(t)
+
+	if _, err := FanOut(VM, 0, "lab", true); !errors.Is(err, ErrInvalidArgument) {
+		t.Fatalf("expected ErrInvalidArgument, got %v", err)
+	}
+	if ManageMock != nil {
+		vmInfoOut := ReadTestData("vboxmanage-showvminfo-1.out")
+		gomock.InOrder(
+			ManageMock.EXPECT().runOutErr("snapshot", VM, "list", "--machinereadable").
+				Return("", "This machine does not have any snapshots", errors.New("exit status 1")).Times(1),
+			ManageMock.EXPECT().run("snapshot", VM, "take", "lab-base", "--description", "Base of the linked clones lab-N", "--live").Return(nil).Times(1),
+			ManageMock.EXPECT().run("clonevm", VM, "--name", "lab-1", "--register", "--snapshot", "lab-base", "--options", "link").Return(nil).Times(1),
+			ManageMock.EXPECT().run("clonevm", VM, "--name", "lab-2", "--register", "--snapshot", "lab-base", "--options", "link").Return(nil).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", "lab-1", "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", "lab-2", "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+		)
+		ms, err := FanOut(VM, 2, "lab", true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(ms) != 2 {
+			t.Fatalf("expected 2 machines, got %d", len(ms))
+		}
+
+		gomock.InOrder(
+			ManageMock.EXPECT().run("clonevm", VM, "--name", "lab-1", "--register").Return(nil).Times(1),
+			ManageMock.EXPECT().run("clonevm", VM, "--name", "lab-2", "--register").Return(errors.New("exit status 1")).Times(1),
+			ManageMock.EXPECT().run("unregistervm", "lab-1", "--delete").Return(nil).Times(1),
+		)
+		if _, err := FanOut(VM, 2, "lab", false); err == nil || !strings.Contains(err.Error(), "cloning 'lab-2'") {
+			t.Fatalf("expected the failed clone to be reported, got %v", err)
+		}
+	}
+
+	Teardown()
+}
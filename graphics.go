@@ -0,0 +1,75 @@
+package virtualbox
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrGraphicsMismatch holds the error message when a graphics controller is not recommended for the guest OS.
+	ErrGraphicsMismatch = errors.New("graphics controller not recommended for the guest OS")
+)
+
+// GraphicsController represents the emulated graphics card of a machine.
+type GraphicsController string
+
+const (
+	// GraphicsNone when the machine has no graphics card.
+	GraphicsNone = GraphicsController("none")
+	// GraphicsVBoxVGA is the legacy controller, for old Windows and other OSes.
+	GraphicsVBoxVGA = GraphicsController("vboxvga")
+	// GraphicsVMSVGA emulates a VMware SVGA card, for Linux and most other OSes.
+	GraphicsVMSVGA = GraphicsController("vmsvga")
+	// GraphicsVBoxSVGA is the default for Windows Vista and later.
+	GraphicsVBoxSVGA = GraphicsController("vboxsvga")
+)
+
+// legacyOSTypes are the OS type id prefixes for which VirtualBox recommends
+// GraphicsVBoxVGA.
+var legacyOSTypes = []string{
+	"Windows31", "Windows95", "Windows98", "WindowsMe", "WindowsNT", "Windows2000",
+	"WindowsXP", "Windows2003", "DOS", "OS2", "Other",
+}
+
+// RecommendGraphicsController returns the graphics controller VirtualBox
+// recommends for the given OS type id, e.g. "Ubuntu_64".
+func RecommendGraphicsController(osType string) GraphicsController {
+	for _, prefix := range legacyOSTypes {
+		if strings.HasPrefix(osType, prefix) {
+			return GraphicsVBoxVGA
+		}
+	}
+	if strings.HasPrefix(osType, "Windows") {
+		return GraphicsVBoxSVGA
+	}
+	return GraphicsVMSVGA
+}
+
+// CheckGraphicsController tells whether the graphics controller suits the OS
+// type id, e.g. GraphicsVBoxVGA leaves modern Linux guests with a black screen.
+// The returned error wraps ErrGraphicsMismatch and is only advisory:
+// SetGraphicsController does not check it, so that it can be overridden.
+func CheckGraphicsController(osType string, ctl GraphicsController) error {
+	if ctl == GraphicsNone {
+		return nil
+	}
+	if rec := RecommendGraphicsController(osType); ctl != rec {
+		return fmt.Errorf("%w: %s for %s, %s recommended", ErrGraphicsMismatch, ctl, osType, rec)
+	}
+	return nil
+}
+
+// SetGraphicsController sets the graphics controller of the machine, which
+// must not be running. See CheckGraphicsController.
+func SetGraphicsController(vm string, ctl GraphicsController) error {
+	switch ctl {
+	case GraphicsNone, GraphicsVBoxVGA, GraphicsVMSVGA, GraphicsVBoxSVGA:
+	default:
+		return fmt.Errorf("invalid graphics controller: '%s'", ctl)
+	}
+	if err := assertMutable(vm); err != nil {
+		return err
+	}
+	return Manage().run("modifyvm", vm, "--graphicscontroller", string(ctl))
+}
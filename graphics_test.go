@@ -0,0 +1,47 @@
+package virtualbox
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestRecommendGraphicsController(t *testing.T) {
+	for osType, expected := range map[string]GraphicsController{
+		"Ubuntu_64":    GraphicsVMSVGA,
+		"Windows11_64": GraphicsVBoxSVGA,
+		"WindowsXP":    GraphicsVBoxVGA,
+		"Other":        GraphicsVBoxVGA,
+	} {
+		if ctl := RecommendGraphicsController(osType); ctl != expected {
+			t.Errorf("expected %s for %s, got %s", expected, osType, ctl)
+		}
+	}
+	if err := CheckGraphicsController("Ubuntu_64", GraphicsVBoxVGA); !errors.Is(err, ErrGraphicsMismatch) {
+		t.Fatalf("expected ErrGraphicsMismatch, got %v", err)
+	}
+	if err := CheckGraphicsController("Ubuntu_64", GraphicsVMSVGA); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSetGraphicsController(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		vmInfoOut := ReadTestData("vboxmanage-showvminfo-poweroff.out")
+		gomock.InOrder(
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+			ManageMock.EXPECT().run("modifyvm", VM, "--graphicscontroller", "vmsvga").Return(nil).Times(1),
+		)
+		if err := SetGraphicsController(VM, GraphicsVMSVGA); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := SetGraphicsController(VM, "cirrus"); err == nil {
+		t.Fatal("expected an error for an invalid graphics controller")
+	}
+
+	Teardown()
+}
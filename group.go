@@ -0,0 +1,124 @@
+package virtualbox
+
+import (
+	"bufio"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ListGroups lists the paths of the machine groups, e.g. "/" or "/prod/web".
+func ListGroups() ([]string, error) {
+	out, err := Manage().runOut("list", "groups")
+	if err != nil {
+		return nil, err
+	}
+	var groups []string
+	s := bufio.NewScanner(strings.NewReader(out))
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" {
+			continue
+		}
+		groups = append(groups, strings.TrimSuffix(strings.TrimPrefix(line, `"`), `"`))
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
+
+// splitGroups splits the comma separated groups of a machine, as reported by
+// showvminfo.
+func splitGroups(val string) []string {
+	if val == "" {
+		return nil
+	}
+	return strings.Split(val, ",")
+}
+
+// MachinesByGroup lists the machines of each group, keyed by group path. All
+// the groups are present, empty ones included, so that the whole group tree
+// can be rendered. A machine belonging to several groups is listed in each.
+func MachinesByGroup() (map[string][]*Machine, error) {
+	groups, err := ListGroups()
+	if err != nil {
+		return nil, err
+	}
+	ms, err := ListMachines()
+	if err != nil {
+		return nil, err
+	}
+	tree := make(map[string][]*Machine, len(groups))
+	for _, g := range groups {
+		tree[g] = []*Machine{}
+	}
+	for _, m := range ms {
+		for _, g := range m.Groups {
+			tree[g] = append(tree[g], m)
+		}
+	}
+	for _, ms := range tree {
+		sort.Slice(ms, func(i, j int) bool { return ms[i].Name < ms[j].Name })
+	}
+	return tree, nil
+}
+
+// ValidateGroupPath checks a machine group path, e.g. "/" or "/prod/web": it
+// must be absolute, and made of non-empty names without commas, which
+// separate the groups of a machine.
+func ValidateGroupPath(group string) error {
+	if group == "/" {
+		return nil
+	}
+	if !strings.HasPrefix(group, "/") {
+		return fmt.Errorf("%w: group path '%s' is not absolute", ErrInvalidArgument, group)
+	}
+	for _, name := range strings.Split(group[1:], "/") {
+		if name == "" || strings.TrimSpace(name) != name || strings.ContainsRune(name, ',') {
+			return fmt.Errorf("%w: invalid group path '%s'", ErrInvalidArgument, group)
+		}
+	}
+	return nil
+}
+
+// RelocateMachine renames the machine and moves it to the given group, which
+// replaces all its groups. An empty newName or newGroup leaves the name or the
+// group unchanged; a group qualified newName gives the group too. Both changes
+// are made in a single modifyvm call, which VirtualBox only saves when both
+// succeed. The machine must not be running. It returns the relocated machine.
+func RelocateMachine(vm, newName, newGroup string) (*Machine, error) {
+	args := []string{"modifyvm", vm}
+	if newName != "" {
+		if err := ValidateMachineName(newName); err != nil {
+			return nil, err
+		}
+		group, base := splitMachineName(newName)
+		if group != "" && newGroup != "" {
+			return nil, fmt.Errorf("%w: both a group qualified name '%s' and a group '%s'", ErrInvalidArgument, newName, newGroup)
+		}
+		if group != "" {
+			newGroup = group
+		}
+		newName = base
+		args = append(args, "--name", newName)
+	}
+	if newGroup != "" {
+		if err := ValidateGroupPath(newGroup); err != nil {
+			return nil, err
+		}
+		args = append(args, "--groups", newGroup)
+	}
+	if err := assertMutableOrSaved(vm); err != nil {
+		return nil, err
+	}
+	if len(args) > 2 {
+		if err := Manage().run(args...); err != nil {
+			return nil, err
+		}
+	}
+	if newName != "" {
+		vm = newName
+	}
+	return GetMachine(vm)
+}
@@ -0,0 +1,72 @@
+package virtualbox
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestMachinesByGroup(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		listGroupsOut := ReadTestData("vboxmanage-list-groups-1.out")
+		listVmsOut := ReadTestData("vboxmanage-list-vms-1.out")
+		vmInfoOut := ReadTestData("vboxmanage-showvminfo-1.out")
+		webInfoOut := strings.Replace(vmInfoOut, `groups="/"`, `groups="/prod/web servers,/prod"`, 1)
+		gomock.InOrder(
+			ManageMock.EXPECT().runOut("list", "groups").Return(listGroupsOut, nil).Times(1),
+			ManageMock.EXPECT().runOut("list", "vms").Return(listVmsOut, nil).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", "Ubuntu", "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", "go-virtualbox", "--machinereadable").Return(webInfoOut, "", nil).Times(1),
+		)
+		tree, err := MachinesByGroup()
+		if err != nil {
+			t.Fatal(err)
+		}
+		counts := map[string]int{}
+		for g, ms := range tree {
+			counts[g] = len(ms)
+		}
+		expected := map[string]int{"/": 1, "/prod": 1, "/prod/web servers": 1}
+		if !reflect.DeepEqual(counts, expected) {
+			t.Fatalf("expected %v, got %v", expected, counts)
+		}
+	}
+
+	Teardown()
+}
+
+func TestRelocateMachine(t *testing.T) {
+	Setup(t)
+
+	for _, group := range []string{"prod", "/prod/", "//prod", "/prod,/dev", "/ prod"} {
+		if err := ValidateGroupPath(group); err == nil {
+			t.Errorf("expected '%s' to be invalid", group)
+		}
+	}
+	if _, err := RelocateMachine(VM, "web/1", "/prod"); err == nil {
+		t.Fatal("expected an error for an invalid name")
+	}
+	if ManageMock != nil {
+		vmInfoOut := ReadTestData("vboxmanage-showvminfo-1.out")
+		relocated := strings.Replace(strings.Replace(vmInfoOut, `name="go-virtualbox"`, `name="web-1"`, 1),
+			`groups="/"`, `groups="/prod/web"`, 1)
+		gomock.InOrder(
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+			ManageMock.EXPECT().run("modifyvm", VM, "--name", "web-1", "--groups", "/prod/web").Return(nil).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", "web-1", "--machinereadable").Return(relocated, "", nil).Times(1),
+		)
+		m, err := RelocateMachine(VM, "web-1", "/prod/web")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m.Name != "web-1" || !reflect.DeepEqual(m.Groups, []string{"/prod/web"}) {
+			t.Fatalf("unexpected machine: %s in %q", m.Name, m.Groups)
+		}
+	}
+
+	Teardown()
+}
@@ -0,0 +1,102 @@
+package virtualbox
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+const guestAdditionsISO = "VBoxGuestAdditions.iso"
+
+// guestAdditionsISOLocations lists where VirtualBox installers put the Guest
+// Additions ISO, per host OS.
+var guestAdditionsISOLocations = map[string][]string{
+	"linux": {
+		"/usr/share/virtualbox/" + guestAdditionsISO,
+		"/usr/lib/virtualbox/additions/" + guestAdditionsISO,
+		"/opt/VirtualBox/additions/" + guestAdditionsISO,
+	},
+	"darwin": {
+		"/Applications/VirtualBox.app/Contents/MacOS/" + guestAdditionsISO,
+	},
+	osWindows: {
+		`C:\Program Files\Oracle\VirtualBox\` + guestAdditionsISO,
+	},
+}
+
+// GuestAdditionsISO locates the Guest Additions ISO bundled with VirtualBox on
+// the host: the one VirtualBox reports first, then the installation folders.
+func GuestAdditionsISO() (string, error) {
+	var candidates []string
+	if p, err := GetSystemProperties(); err == nil && p.DefaultGuestAdditionsISO != "" {
+		candidates = append(candidates, p.DefaultGuestAdditionsISO)
+	}
+	for _, env := range []string{"VBOX_MSI_INSTALL_PATH", "VBOX_INSTALL_PATH"} {
+		if dir := os.Getenv(env); dir != "" {
+			candidates = append(candidates, filepath.Join(dir, guestAdditionsISO))
+		}
+	}
+	candidates = append(candidates, guestAdditionsISOLocations[runtime.GOOS]...)
+
+	for _, path := range candidates {
+		if fi, err := os.Stat(path); err == nil && !fi.IsDir() {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("%s not found, looked for %q: %w", guestAdditionsISO, candidates, os.ErrNotExist)
+}
+
+// MountGuestAdditionsISO inserts the Guest Additions ISO of the host into the
+// first DVD drive of the machine.
+func MountGuestAdditionsISO(vm string) error {
+	iso, err := GuestAdditionsISO()
+	if err != nil {
+		return err
+	}
+	attachments, err := ListAttachments(vm)
+	if err != nil {
+		return err
+	}
+	for _, a := range attachments {
+		if a.DriveType != DriveDVD {
+			continue
+		}
+		return Manage().run("storageattach", vm, "--storagectl", a.Controller,
+			"--port", fmt.Sprintf("%d", a.Port),
+			"--device", fmt.Sprintf("%d", a.Device),
+			"--type", string(DriveDVD),
+			"--medium", iso,
+		)
+	}
+	return fmt.Errorf("machine '%s' has no DVD drive to mount %s", vm, guestAdditionsISO)
+}
+
+// GuestOS describes the OS running in the guest, as reported by the Guest
+// Additions. It may differ from the OS type of the machine settings.
+type GuestOS struct {
+	Product     string `json:"product"` // e.g. "Linux" or "Windows 10"
+	Release     string `json:"release"` // e.g. kernel "5.4.0-42-generic" or build "10.0.19041"
+	Version     string `json:"version"`
+	ServicePack string `json:"service_pack"` // empty when not reported
+}
+
+// GuestOSInfo reads the OS running in the guest from the guest properties the
+// Guest Additions set. It returns an error wrapping ErrGuestAdditionsRequired
+// when they have not reported the OS yet, e.g. while the guest boots.
+func GuestOSInfo(vm string) (*GuestOS, error) {
+	product, err := GetGuestProperty(vm, "/VirtualBox/GuestInfo/OS/Product")
+	if errors.Is(err, ErrGuestPropertyNotSet) || (err == nil && product == "") {
+		return nil, fmt.Errorf("guest OS of '%s' is not reported: %w", vm, ErrGuestAdditionsRequired)
+	}
+	if err != nil {
+		return nil, err
+	}
+	info := &GuestOS{Product: product}
+	// Not all the guest OSes report these.
+	info.Release, _ = GetGuestProperty(vm, "/VirtualBox/GuestInfo/OS/Release")
+	info.Version, _ = GetGuestProperty(vm, "/VirtualBox/GuestInfo/OS/Version")
+	info.ServicePack, _ = GetGuestProperty(vm, "/VirtualBox/GuestInfo/OS/ServicePack")
+	return info, nil
+}
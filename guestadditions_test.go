@@ -0,0 +1,77 @@
+package virtualbox
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestMountGuestAdditionsISO(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		dir, err := ioutil.TempDir("", "go-virtualbox-test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+		iso := filepath.Join(dir, "VBoxGuestAdditions.iso")
+		if err := ioutil.WriteFile(iso, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		propsOut := strings.Replace(ReadTestData("vboxmanage-list-systemproperties-1.out"),
+			"/Applications/VirtualBox.app/Contents/MacOS/VBoxGuestAdditions.iso", iso, 1)
+		vmInfoOut := strings.Replace(ReadTestData("vboxmanage-showvminfo-1.out"),
+			`"IDE Controller-1-0"="none"`, `"IDE Controller-1-0"="emptydrive"`, 1)
+		gomock.InOrder(
+			ManageMock.EXPECT().runOut("list", "systemproperties").Return(propsOut, nil).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+			ManageMock.EXPECT().run("storageattach", VM, "--storagectl", "IDE Controller",
+				"--port", "1", "--device", "0", "--type", "dvddrive", "--medium", iso).Return(nil).Times(1),
+		)
+		if err := MountGuestAdditionsISO(VM); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	Teardown()
+}
+
+func TestGuestOSInfo(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		vboxErr := errors.New("exit status 1")
+		ManageMock.EXPECT().isGuest().Return(false).AnyTimes()
+		gomock.InOrder(
+			ManageMock.EXPECT().runOut("guestproperty", "get", VM, "/VirtualBox/GuestInfo/OS/Product").Return("Value: Linux", nil).Times(1),
+			ManageMock.EXPECT().runOut("guestproperty", "get", VM, "/VirtualBox/GuestInfo/OS/Release").Return("Value: 5.4.0-42-generic", nil).Times(1),
+			ManageMock.EXPECT().runOut("guestproperty", "get", VM, "/VirtualBox/GuestInfo/OS/Version").Return("Value: #46-Ubuntu SMP Fri Jul 10 00:24:02 UTC 2020", nil).Times(1),
+			ManageMock.EXPECT().runOut("guestproperty", "get", VM, "/VirtualBox/GuestInfo/OS/ServicePack").Return("No value set!", nil).Times(1),
+			ManageMock.EXPECT().runOut("guestproperty", "get", VM, "/VirtualBox/GuestInfo/OS/Product").Return("No value set!", nil).Times(1),
+			ManageMock.EXPECT().runOut("guestproperty", "get", VM, "/VirtualBox/GuestInfo/OS/Product").Return("", vboxErr).Times(1),
+		)
+		info, err := GuestOSInfo(VM)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := GuestOS{Product: "Linux", Release: "5.4.0-42-generic", Version: "#46-Ubuntu SMP Fri Jul 10 00:24:02 UTC 2020"}
+		if *info != expected {
+			t.Fatalf("expected %+v, got %+v", expected, *info)
+		}
+		if _, err := GuestOSInfo(VM); !errors.Is(err, ErrGuestAdditionsRequired) {
+			t.Fatalf("expected ErrGuestAdditionsRequired, got %v", err)
+		}
+		if _, err := GuestOSInfo(VM); err != vboxErr {
+			t.Fatalf("expected the VBoxManage error, got %v", err)
+		}
+	}
+
+	Teardown()
+}
@@ -0,0 +1,359 @@
+package virtualbox
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	reGuestFileNotFound = regexp.MustCompile(`VERR_FILE_NOT_FOUND|VERR_PATH_NOT_FOUND|No such file or directory`)
+	reGuestSessionLine  = regexp.MustCompile(`Session #\s*\d+\s+ID=(\d+)`)
+	reGuestProcessLine  = regexp.MustCompile(`Process #\s*\d+\s+PID=(\d+)\s+Status=\[([^\]]*)\]\s+Command=(.*)$`)
+)
+
+var (
+	// ErrGuestFileNotExist holds the error message when a path does not exist inside the guest.
+	ErrGuestFileNotExist = errors.New("guest file does not exist")
+	// ErrGuestSessionClosed holds the error message when a closed GuestSession is used.
+	ErrGuestSessionClosed = errors.New("guest session is closed")
+)
+
+// GuestCredentials holds the guest account used to run guest control operations.
+type GuestCredentials struct {
+	Username string
+	Password string
+	Domain   string // optional
+}
+
+// GuestFileInfo describes a file system object inside the guest.
+type GuestFileInfo struct {
+	Path  string
+	Type  string // file|directory|symlink, as reported by the Guest Additions
+	Size  uint64
+	Mode  string // e.g. "-rw-r--r--", when reported
+	IsDir bool
+}
+
+// GuestSession runs guest control operations in a machine with a fixed set
+// of credentials. VBoxManage opens a guest session on each invocation, so the
+// session mostly saves re-validating and re-writing the credentials: the
+// password is written once to a private file, which is removed on Close.
+type GuestSession struct {
+	vm           string
+	creds        GuestCredentials
+	passwordFile string
+	closed       bool
+}
+
+// OpenGuestSession prepares a GuestSession to run operations in the given machine.
+func OpenGuestSession(vm string, creds GuestCredentials) (*GuestSession, error) {
+	if creds.Username == "" {
+		return nil, fmt.Errorf("guest username is empty")
+	}
+	gs := &GuestSession{vm: vm, creds: creds}
+	if creds.Password != "" {
+		// The file outlives a call, withSecretFile does not fit.
+		path, err := writeSecretFile(creds.Password)
+		if err != nil {
+			return nil, err
+		}
+		gs.passwordFile = path
+	}
+	return gs, nil
+}
+
+// Close releases the session resources. It is safe to call more than once.
+func (gs *GuestSession) Close() error {
+	if gs.closed {
+		return nil
+	}
+	gs.closed = true
+	if gs.passwordFile != "" {
+		return removeSecretFile(gs.passwordFile)
+	}
+	return nil
+}
+
+// guestFileSubcommands are the guestcontrol subcommands whose errors may be
+// about a missing guest file, see ErrGuestFileNotExist.
+var guestFileSubcommands = map[string]bool{
+	"copyfrom": true,
+	"copyto":   true,
+	"rm":       true,
+	"rmdir":    true,
+	"stat":     true,
+}
+
+// run runs the given guestcontrol subcommand with the session credentials.
+func (gs *GuestSession) run(subcmd string, args ...string) (string, error) {
+	if gs.closed {
+		return "", ErrGuestSessionClosed
+	}
+	argv := []string{"guestcontrol", gs.vm, subcmd, "--username", gs.creds.Username}
+	if gs.passwordFile != "" {
+		argv = append(argv, "--passwordfile", gs.passwordFile)
+	}
+	if gs.creds.Domain != "" {
+		argv = append(argv, "--domain", gs.creds.Domain)
+	}
+	argv = append(argv, args...)
+	stdout, stderr, err := Manage().runOutErr(argv...)
+	if err != nil {
+		switch {
+		case reMachineNotFound.MatchString(stderr):
+			return "", ErrMachineNotExist
+		case reMachineNotRunning.MatchString(stderr):
+			return "", ErrMachineNotRunning
+		case guestFileSubcommands[subcmd] && reGuestFileNotFound.MatchString(stderr):
+			return "", ErrGuestFileNotExist
+		}
+		if msg := strings.TrimSpace(stderr); msg != "" {
+			return stdout, fmt.Errorf("guestcontrol %s: %s", subcmd, msg)
+		}
+		return stdout, err
+	}
+	return stdout, nil
+}
+
+// Run runs the given executable in the guest, waits for it to exit and
+// returns its standard output.
+func (gs *GuestSession) Run(exe string, args ...string) (string, error) {
+	argv := []string{"--exe", exe, "--wait-stdout", "--", exe}
+	return gs.run("run", append(argv, args...)...)
+}
+
+// CopyTo copies the host file src to dst inside the guest.
+func (gs *GuestSession) CopyTo(src, dst string) error {
+	_, err := gs.run("copyto", src, dst)
+	return err
+}
+
+// CopyFrom copies the guest file src to dst on the host.
+func (gs *GuestSession) CopyFrom(src, dst string) error {
+	_, err := gs.run("copyfrom", src, dst)
+	return err
+}
+
+// Mkdir creates a directory inside the guest, along with any missing parent
+// directories if parents is true.
+func (gs *GuestSession) Mkdir(path string, parents bool) error {
+	args := []string{}
+	if parents {
+		args = append(args, "--parents")
+	}
+	_, err := gs.run("mkdir", append(args, path)...)
+	return err
+}
+
+// Remove removes the given file inside the guest.
+func (gs *GuestSession) Remove(path string) error {
+	_, err := gs.run("rm", path)
+	return err
+}
+
+// Rmdir removes the given directory inside the guest, along with its content
+// if recursive is true.
+func (gs *GuestSession) Rmdir(path string, recursive bool) error {
+	args := []string{}
+	if recursive {
+		args = append(args, "--recursive")
+	}
+	_, err := gs.run("rmdir", append(args, path)...)
+	return err
+}
+
+// Stat describes the given file system object inside the guest, or returns
+// ErrGuestFileNotExist when it does not exist.
+func (gs *GuestSession) Stat(path string) (GuestFileInfo, error) {
+	out, err := gs.run("stat", path)
+	if err != nil {
+		return GuestFileInfo{}, err
+	}
+	return parseGuestStat(path, out)
+}
+
+// GuestProcess describes a guest process started through guest control.
+type GuestProcess struct {
+	PID       int
+	Name      string // command, as reported by the Guest Additions
+	Status    string // e.g. "started" or "terminated normally"
+	SessionID int    // of the guest session the process runs in
+}
+
+// ListProcesses lists the guest processes started through guest control, in
+// any guest session of the machine.
+func (gs *GuestSession) ListProcesses() ([]GuestProcess, error) {
+	out, err := gs.run("list", "processes")
+	if err != nil {
+		return nil, err
+	}
+	return parseGuestProcesses(out)
+}
+
+// parseGuestProcesses parses the output of 'guestcontrol list processes',
+// where each session line is followed by the lines of its processes.
+func parseGuestProcesses(out string) ([]GuestProcess, error) {
+	var procs []GuestProcess
+	session := 0
+	s := bufio.NewScanner(strings.NewReader(out))
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if res := reGuestSessionLine.FindStringSubmatch(line); res != nil {
+			session, _ = strconv.Atoi(res[1])
+			continue
+		}
+		res := reGuestProcessLine.FindStringSubmatch(line)
+		if res == nil {
+			continue
+		}
+		pid, err := strconv.Atoi(res[1])
+		if err != nil {
+			return nil, err
+		}
+		procs = append(procs, GuestProcess{
+			PID:       pid,
+			Name:      strings.TrimSpace(res[3]),
+			Status:    strings.ToLower(strings.TrimSpace(res[2])),
+			SessionID: session,
+		})
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return procs, nil
+}
+
+// KillProcess terminates the guest process with the given PID, which must have
+// been started through guest control.
+func (gs *GuestSession) KillProcess(pid int) error {
+	procs, err := gs.ListProcesses()
+	if err != nil {
+		return err
+	}
+	for _, p := range procs {
+		if p.PID == pid {
+			_, err := gs.run("closeprocess", "--session-id", strconv.Itoa(p.SessionID), strconv.Itoa(pid))
+			return err
+		}
+	}
+	return fmt.Errorf("no guest process with PID %d in '%s'", pid, gs.vm)
+}
+
+// withGuestSession runs fn in a one-shot GuestSession.
+func withGuestSession(vm string, creds GuestCredentials, fn func(*GuestSession) error) error {
+	gs, err := OpenGuestSession(vm, creds)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = gs.Close() }()
+	return fn(gs)
+}
+
+// GuestMkdir creates a directory inside the guest, along with any missing
+// parent directories if parents is true.
+func GuestMkdir(vm string, creds GuestCredentials, path string, parents bool) error {
+	return withGuestSession(vm, creds, func(gs *GuestSession) error {
+		return gs.Mkdir(path, parents)
+	})
+}
+
+// GuestRemove removes the given file inside the guest.
+func GuestRemove(vm string, creds GuestCredentials, path string) error {
+	return withGuestSession(vm, creds, func(gs *GuestSession) error {
+		return gs.Remove(path)
+	})
+}
+
+// GuestRmdir removes the given directory inside the guest, along with its
+// content if recursive is true.
+func GuestRmdir(vm string, creds GuestCredentials, path string, recursive bool) error {
+	return withGuestSession(vm, creds, func(gs *GuestSession) error {
+		return gs.Rmdir(path, recursive)
+	})
+}
+
+// ListGuestProcesses lists the guest processes started through guest control.
+func ListGuestProcesses(vm string, creds GuestCredentials) ([]GuestProcess, error) {
+	var procs []GuestProcess
+	err := withGuestSession(vm, creds, func(gs *GuestSession) error {
+		var err error
+		procs, err = gs.ListProcesses()
+		return err
+	})
+	return procs, err
+}
+
+// KillGuestProcess terminates the guest process with the given PID, which must
+// have been started through guest control.
+func KillGuestProcess(vm string, creds GuestCredentials, pid int) error {
+	return withGuestSession(vm, creds, func(gs *GuestSession) error {
+		return gs.KillProcess(pid)
+	})
+}
+
+// GuestStat describes the given file system object inside the guest, or
+// returns ErrGuestFileNotExist when it does not exist.
+func GuestStat(vm string, creds GuestCredentials, path string) (GuestFileInfo, error) {
+	var info GuestFileInfo
+	err := withGuestSession(vm, creds, func(gs *GuestSession) error {
+		var err error
+		info, err = gs.Stat(path)
+		return err
+	})
+	return info, err
+}
+
+// parseGuestStat parses the output of guestcontrol stat, which varies between
+// VirtualBox releases: older ones only print whether the element is a file or
+// a directory, newer ones print "Key: value" details.
+func parseGuestStat(path, out string) (GuestFileInfo, error) {
+	info := GuestFileInfo{Path: path}
+	s := bufio.NewScanner(strings.NewReader(out))
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		switch {
+		case strings.Contains(line, "Is a directory"):
+			info.Type = "directory"
+		case strings.Contains(line, "Is a file"):
+			info.Type = "file"
+		case strings.Contains(line, "Is a symbolic link"):
+			info.Type = "symlink"
+		}
+		res := reColonLine.FindStringSubmatch(line)
+		if res == nil {
+			continue
+		}
+		switch key, val := strings.TrimSpace(res[1]), strings.TrimSpace(res[2]); key {
+		case "Type":
+			info.Type = firstField(val)
+		case "Size":
+			if val == "" {
+				continue
+			}
+			n, err := strconv.ParseUint(firstField(val), 10, 64)
+			if err != nil {
+				return info, err
+			}
+			info.Size = n
+		case "Mode":
+			info.Mode = firstField(val)
+		}
+	}
+	if err := s.Err(); err != nil {
+		return info, err
+	}
+	info.IsDir = info.Type == "directory"
+	return info, nil
+}
+
+// firstField returns the first whitespace-separated field of s, if any.
+func firstField(s string) string {
+	if f := strings.Fields(s); len(f) > 0 {
+		return f[0]
+	}
+	return ""
+}
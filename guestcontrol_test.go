@@ -0,0 +1,125 @@
+package virtualbox
+
+import (
+	"errors"
+	"io/ioutil"
+	"reflect"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestGuestSession(t *testing.T) {
+	Setup(t)
+
+	gs, err := OpenGuestSession(VM, GuestCredentials{Username: "vagrant", Password: "s3cr3t"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(gs.passwordFile)
+	if err != nil || string(b) != "s3cr3t" {
+		t.Fatalf("unexpected password file content %q (%v)", b, err)
+	}
+
+	if ManageMock != nil {
+		gomock.InOrder(
+			ManageMock.EXPECT().runOutErr("guestcontrol", VM, "mkdir", "--username", "vagrant", "--passwordfile", gs.passwordFile, "--parents", "/opt/app").Return("", "", nil).Times(1),
+			ManageMock.EXPECT().runOutErr("guestcontrol", VM, "stat", "--username", "vagrant", "--passwordfile", gs.passwordFile, "/opt/app").Return("Element \"/opt/app\" found: Is a directory\n", "", nil).Times(1),
+		)
+	}
+	if err := gs.Mkdir("/opt/app", true); err != nil {
+		t.Fatal(err)
+	}
+	info, err := gs.Stat("/opt/app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ManageMock != nil && !info.IsDir {
+		t.Fatalf("expected a directory, got %+v", info)
+	}
+
+	if err := gs.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := gs.Run("/bin/true"); err != ErrGuestSessionClosed {
+		t.Fatalf("expected ErrGuestSessionClosed, got %v", err)
+	}
+
+	Teardown()
+}
+
+func TestGuestStat(t *testing.T) {
+	Setup(t)
+
+	creds := GuestCredentials{Username: "vagrant"}
+	if ManageMock != nil {
+		out := "  File: '/etc/hosts'\n  Type: file\n  Size: 220\n  Mode: -rw-r--r--\n"
+		stderr := "VBoxManage: error: Cannot stat for element \"/nope\": No such file or directory\n"
+		gomock.InOrder(
+			ManageMock.EXPECT().runOutErr("guestcontrol", VM, "stat", "--username", "vagrant", "/etc/hosts").Return(out, "", nil).Times(1),
+			ManageMock.EXPECT().runOutErr("guestcontrol", VM, "stat", "--username", "vagrant", "/nope").Return("", stderr, errors.New("exit status 1")).Times(1),
+		)
+	}
+	info, err := GuestStat(VM, creds, "/etc/hosts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("%+v", info)
+	if ManageMock != nil {
+		if info.Type != "file" || info.Size != 220 || info.Mode != "-rw-r--r--" || info.IsDir {
+			t.Fatalf("unexpected file info: %+v", info)
+		}
+		if _, err := GuestStat(VM, creds, "/nope"); err != ErrGuestFileNotExist {
+			t.Fatalf("expected ErrGuestFileNotExist, got %v", err)
+		}
+
+		gomock.InOrder(
+			ManageMock.EXPECT().runOutErr("guestcontrol", VM, "rm", "--username", "vagrant", "/nope").
+				Return("", "VBoxManage: error: Removing file \"/nope\" failed: VERR_FILE_NOT_FOUND\n", errors.New("exit status 1")).Times(1),
+			ManageMock.EXPECT().runOutErr("guestcontrol", VM, "mkdir", "--username", "vagrant", "/data").
+				Return("", "VBoxManage: error: VBoxService not found\n", errors.New("exit status 1")).Times(1),
+		)
+		if err := GuestRemove(VM, creds, "/nope"); err != ErrGuestFileNotExist {
+			t.Fatalf("expected ErrGuestFileNotExist, got %v", err)
+		}
+		if err := GuestMkdir(VM, creds, "/data", false); err == nil || err == ErrGuestFileNotExist {
+			t.Fatalf("expected the guestcontrol error, got %v", err)
+		}
+	}
+
+	Teardown()
+}
+
+func TestGuestProcesses(t *testing.T) {
+	Setup(t)
+
+	creds := GuestCredentials{Username: "vagrant"}
+	if ManageMock != nil {
+		out := "\n\tSession #0   ID=1   User=vagrant          Status=[started] Name=jobs\n" +
+			"\t\tProcess #0   PID=1234   Status=[started] Command=/usr/bin/sleep\n" +
+			"\t\tProcess #1   PID=1240   Status=[terminated normally] Command=/bin/true\n" +
+			"\n\tSession #1   ID=3   User=vagrant          Status=[started] Name=other\n" +
+			"\t\tProcess #0   PID=2000   Status=[started] Command=/usr/bin/tail\n"
+		gomock.InOrder(
+			ManageMock.EXPECT().runOutErr("guestcontrol", VM, "list", "--username", "vagrant", "processes").Return(out, "", nil).Times(2),
+			ManageMock.EXPECT().runOutErr("guestcontrol", VM, "closeprocess", "--username", "vagrant", "--session-id", "3", "2000").Return("", "", nil).Times(1),
+		)
+		procs, err := ListGuestProcesses(VM, creds)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := []GuestProcess{
+			{PID: 1234, Name: "/usr/bin/sleep", Status: "started", SessionID: 1},
+			{PID: 1240, Name: "/bin/true", Status: "terminated normally", SessionID: 1},
+			{PID: 2000, Name: "/usr/bin/tail", Status: "started", SessionID: 3},
+		}
+		if !reflect.DeepEqual(procs, expected) {
+			t.Fatalf("expected %+v, got %+v", expected, procs)
+		}
+		if err := KillGuestProcess(VM, creds, 2000); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	Teardown()
+}
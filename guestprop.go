@@ -1,6 +1,7 @@
 package virtualbox
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"regexp"
@@ -19,6 +20,9 @@ var (
 	waitRegexp = regexp.MustCompile("^Name: ([^,]*), value: ([^,]*), flags:.*$")
 )
 
+// ErrGuestPropertyNotSet holds the error message when a guestproperty has no value.
+var ErrGuestPropertyNotSet = errors.New("guest property not set")
+
 // SetGuestProperty writes a VirtualBox guestproperty to the given value.
 func SetGuestProperty(vm string, prop string, val string) error {
 	if Manage().isGuest() {
@@ -27,6 +31,50 @@ func SetGuestProperty(vm string, prop string, val string) error {
 	return Manage().run("guestproperty", "set", vm, prop, val)
 }
 
+// GuestPropertyFlag restricts who may change a guestproperty, or how long it lives.
+type GuestPropertyFlag string
+
+const (
+	// GuestPropTransient drops the property when the machine is powered off.
+	GuestPropTransient = GuestPropertyFlag("TRANSIENT")
+	// GuestPropTransReset drops the property when the machine is powered off or reset.
+	GuestPropTransReset = GuestPropertyFlag("TRANSRESET")
+	// GuestPropReadOnlyGuest keeps the guest from changing the property.
+	GuestPropReadOnlyGuest = GuestPropertyFlag("RDONLYGUEST")
+	// GuestPropReadOnlyHost keeps the host from changing the property.
+	GuestPropReadOnlyHost = GuestPropertyFlag("RDONLYHOST")
+	// GuestPropReadOnly keeps both sides from changing the property.
+	GuestPropReadOnly = GuestPropertyFlag("READONLY")
+)
+
+// SetGuestPropertyFlags writes a VirtualBox guestproperty to the given value,
+// with the given flags, e.g. GuestPropReadOnlyGuest so that the guest cannot
+// tamper with a provisioning flag set by the host.
+func SetGuestPropertyFlags(vm string, prop string, val string, flags ...GuestPropertyFlag) error {
+	names := make([]string, len(flags))
+	for i, f := range flags {
+		switch f {
+		case GuestPropTransient, GuestPropTransReset, GuestPropReadOnlyGuest, GuestPropReadOnlyHost, GuestPropReadOnly:
+		default:
+			return fmt.Errorf("invalid guestproperty flag: '%s'", f)
+		}
+		names[i] = string(f)
+	}
+	guest := Manage().isGuest()
+	args := []string{"guestproperty", "set"}
+	if !guest {
+		args = append(args, vm)
+	}
+	args = append(args, prop, val)
+	if len(names) > 0 {
+		args = append(args, "--flags", strings.Join(names, ","))
+	}
+	if guest {
+		return Manage().setOpts(sudo(true)).run(args...)
+	}
+	return Manage().run(args...)
+}
+
 // GetGuestProperty reads a VirtualBox guestproperty.
 func GetGuestProperty(vm string, prop string) (string, error) {
 	var out string
@@ -41,6 +89,9 @@ func GetGuestProperty(vm string, prop string) (string, error) {
 	}
 	out = strings.TrimSpace(out)
 	Debug("out (trimmed): '%s'", out)
+	if strings.HasPrefix(out, "No value set") {
+		return "", fmt.Errorf("%s: %w", prop, ErrGuestPropertyNotSet)
+	}
 	var match = getRegexp.FindStringSubmatch(out)
 	Debug("match:", match)
 	if len(match) != 2 {
@@ -193,3 +193,20 @@ func TestWaitGuestPropertiesQuit(t *testing.T) {
 
 	Teardown()
 }
+
+func TestSetGuestPropertyFlags(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		ManageMock.EXPECT().isGuest().Return(false)
+		ManageMock.EXPECT().run("guestproperty", "set", VM, "/provision/done", "1", "--flags", "TRANSIENT,RDONLYGUEST").Return(nil)
+		if err := SetGuestPropertyFlags(VM, "/provision/done", "1", GuestPropTransient, GuestPropReadOnlyGuest); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := SetGuestPropertyFlags(VM, "/provision/done", "1", "NOTAFLAG"); err == nil {
+		t.Fatal("expected an error for an invalid flag")
+	}
+
+	Teardown()
+}
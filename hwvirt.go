@@ -0,0 +1,74 @@
+package virtualbox
+
+import "fmt"
+
+// HostVirtCaps holds the hardware virtualization features of the host
+// processor.
+type HostVirtCaps struct {
+	HWVirt            bool // VT-x or AMD-V
+	NestedPaging      bool // EPT or RVI
+	UnrestrictedGuest bool // VT-x unrestricted execution (UX)
+	NestedHWVirt      bool // can be passed through to the guest
+}
+
+// HostVirtCapabilities reports the hardware virtualization features of the
+// host processor, as listed by 'list hostinfo'. Features not reported by
+// older VirtualBox releases are reported as unsupported.
+func HostVirtCapabilities() (HostVirtCaps, error) {
+	info, err := hostInfo()
+	if err != nil {
+		return HostVirtCaps{}, err
+	}
+	if _, ok := info["Processor supports HW virtualization"]; !ok {
+		return HostVirtCaps{}, fmt.Errorf("host processor support for HW virtualization is not reported")
+	}
+	return HostVirtCaps{
+		HWVirt:            info["Processor supports HW virtualization"] == "yes",
+		NestedPaging:      info["Processor supports nested paging"] == "yes",
+		UnrestrictedGuest: info["Processor supports unrestricted guest"] == "yes",
+		NestedHWVirt:      info["Processor supports nested HW virtualization"] == "yes",
+	}, nil
+}
+
+// HWVirtConfig holds the hardware virtualization settings of a machine.
+type HWVirtConfig struct {
+	Enabled           bool // use VT-x or AMD-V
+	NestedPaging      bool // requires Enabled
+	UnrestrictedGuest bool // requires Enabled and NestedPaging
+	// Validate checks the settings against HostVirtCapabilities first, so
+	// that unsupported ones fail here rather than when starting the machine.
+	Validate bool
+}
+
+// SetHWVirt sets the hardware virtualization settings of the machine, which
+// must not be running.
+func SetHWVirt(vm string, cfg HWVirtConfig) error {
+	if cfg.NestedPaging && !cfg.Enabled {
+		return fmt.Errorf("nested paging requires HW virtualization")
+	}
+	if cfg.UnrestrictedGuest && !cfg.NestedPaging {
+		return fmt.Errorf("unrestricted guest execution requires nested paging")
+	}
+	if cfg.Validate {
+		caps, err := HostVirtCapabilities()
+		if err != nil {
+			return err
+		}
+		switch {
+		case cfg.Enabled && !caps.HWVirt:
+			return fmt.Errorf("the host processor does not support HW virtualization, or it is disabled in the firmware")
+		case cfg.NestedPaging && !caps.NestedPaging:
+			return fmt.Errorf("the host processor does not support nested paging")
+		case cfg.UnrestrictedGuest && !caps.UnrestrictedGuest:
+			return fmt.Errorf("the host processor does not support unrestricted guest execution")
+		}
+	}
+	if err := assertMutable(vm); err != nil {
+		return err
+	}
+	return Manage().run("modifyvm", vm,
+		"--hwvirtex", bool2string(cfg.Enabled),
+		"--nestedpaging", bool2string(cfg.NestedPaging),
+		"--vtxux", bool2string(cfg.UnrestrictedGuest),
+	)
+}
@@ -0,0 +1,44 @@
+package virtualbox
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestSetHWVirt(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		hostInfoOut := ReadTestData("vboxmanage-list-hostinfo-1.out")
+		noEPT := strings.Replace(hostInfoOut, "nested paging: yes", "nested paging: no", 1)
+		vmInfoOut := ReadTestData("vboxmanage-showvminfo-poweroff.out")
+		gomock.InOrder(
+			ManageMock.EXPECT().runOut("list", "hostinfo").Return(hostInfoOut, nil).Times(1),
+			ManageMock.EXPECT().runOut("list", "hostinfo").Return(hostInfoOut, nil).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+			ManageMock.EXPECT().run("modifyvm", VM, "--hwvirtex", "on", "--nestedpaging", "on", "--vtxux", "on").Return(nil).Times(1),
+			ManageMock.EXPECT().runOut("list", "hostinfo").Return(noEPT, nil).Times(1),
+		)
+		caps, err := HostVirtCapabilities()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if caps != (HostVirtCaps{HWVirt: true, NestedPaging: true, UnrestrictedGuest: true, NestedHWVirt: true}) {
+			t.Fatalf("unexpected capabilities: %+v", caps)
+		}
+		cfg := HWVirtConfig{Enabled: true, NestedPaging: true, UnrestrictedGuest: true, Validate: true}
+		if err := SetHWVirt(VM, cfg); err != nil {
+			t.Fatal(err)
+		}
+		if err := SetHWVirt(VM, cfg); err == nil || !strings.Contains(err.Error(), "nested paging") {
+			t.Fatalf("expected a nested paging error, got %v", err)
+		}
+	}
+	if err := SetHWVirt(VM, HWVirtConfig{NestedPaging: true}); err == nil {
+		t.Fatal("expected an error for nested paging without HW virtualization")
+	}
+
+	Teardown()
+}
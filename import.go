@@ -1,12 +1,288 @@
 package virtualbox
 
-import "strconv"
+import (
+	"archive/tar"
+	"bufio"
+	"context"
+	"crypto/sha1" // #nosec: SHA1 digests are part of the OVF manifest format
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	reManifestLine = regexp.MustCompile(`^(SHA1|SHA256)\s*\((.+)\)\s*=\s*([0-9a-fA-F]+)$`)
+)
+
+// RegistrationTimeout bounds how long ImportOVF waits for the imported machine
+// to be listed by VirtualBox before returning.
+var RegistrationTimeout = 30 * time.Second
+
+// ImportOption customizes ImportOVF.
+type ImportOption func(*importConfig)
+
+type importConfig struct {
+	verifyManifest bool
+	noCleanup      bool
+	baseFolder     string
+	checksumAlgo   string // "SHA1" or "SHA256", with checksum
+	checksum       string
+}
+
+// ImportVerifyManifest checks the digests listed in the manifest of the
+// appliance before importing it.
+func ImportVerifyManifest() ImportOption {
+	return func(cfg *importConfig) {
+		cfg.verifyManifest = true
+	}
+}
+
+// ImportNoCleanup keeps the machine a failed or cancelled import leaves
+// behind, e.g. to inspect it.
+func ImportNoCleanup() ImportOption {
+	return func(cfg *importConfig) {
+		cfg.noCleanup = true
+	}
+}
+
+// ImportBaseFolder imports the machine into a folder under dir, rather than
+// under the default machine folder. The folder is passed to the import of this
+// virtual system only, so concurrent imports into different folders do not
+// interfere.
+func ImportBaseFolder(dir string) ImportOption {
+	return func(cfg *importConfig) {
+		cfg.baseFolder = dir
+	}
+}
+
+// ImportChecksum checks the digest of the appliance file before importing it,
+// e.g. against the SHA256 published along with a cloud image. algo is "SHA1"
+// or "SHA256", and sum is hex encoded.
+func ImportChecksum(algo, sum string) ImportOption {
+	return func(cfg *importConfig) {
+		cfg.checksumAlgo = strings.ToUpper(algo)
+		cfg.checksum = strings.ToLower(sum)
+	}
+}
+
+// verifyChecksum checks the file at path against the ImportChecksum digest.
+func (cfg importConfig) verifyChecksum(path string) error {
+	if cfg.checksumAlgo != "SHA1" && cfg.checksumAlgo != "SHA256" {
+		return fmt.Errorf("%w: unsupported checksum algorithm '%s'", ErrInvalidArgument, cfg.checksumAlgo)
+	}
+	f, err := os.Open(path) // #nosec
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	d, err := digest(f)
+	if err != nil {
+		return fmt.Errorf("reading '%s': %w", path, err)
+	}
+	if d[cfg.checksumAlgo] != cfg.checksum {
+		return fmt.Errorf("%s digest mismatch for '%s': expected %s, got %s", cfg.checksumAlgo, path, cfg.checksum, d[cfg.checksumAlgo])
+	}
+	return nil
+}
 
 //ImportOVF imports ova or ovf from the given path
-func ImportOVF(path string, vsys int, name string) error {
-	return Manage().run(
-		"import", path,
+func ImportOVF(path string, vsys int, name string, opts ...ImportOption) error {
+	return ImportOVFContext(context.Background(), path, vsys, name, opts...)
+}
+
+// ImportOVFContext imports ova or ovf from the given path, interrupting VBoxManage
+// when ctx is done. When the import fails or is cancelled partway, the
+// half-registered machine is unregistered and its disks deleted, unless
+// ImportNoCleanup is given. On success, it waits up to RegistrationTimeout for
+// the machine to be listed, so that it can be modified right away. A group
+// qualified name, e.g. "/prod/web", imports the machine into that group.
+func ImportOVFContext(ctx context.Context, path string, vsys int, name string, opts ...ImportOption) error {
+	if err := ValidateMachineName(name); err != nil {
+		return err
+	}
+	var cfg importConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.checksumAlgo != "" {
+		if err := cfg.verifyChecksum(path); err != nil {
+			return err
+		}
+	}
+	if cfg.verifyManifest {
+		if err := VerifyOVFManifest(path); err != nil {
+			return err
+		}
+	}
+	if cfg.baseFolder != "" {
+		if err := checkWritableDir(cfg.baseFolder); err != nil {
+			return err
+		}
+	}
+
+	// Never clean up a machine which was there before the import.
+	group, name := splitMachineName(name)
+	_, err := machineProps(name)
+	if err != nil && err != ErrMachineNotExist {
+		return err
+	}
+	existed := err == nil
+
+	args := []string{"import", path,
 		"--vsys", strconv.Itoa(vsys),
 		"--vmname", name,
-	)
+	}
+	if group != "" {
+		args = append(args, "--group", group)
+	}
+	if cfg.baseFolder != "" {
+		args = append(args, "--basefolder", cfg.baseFolder)
+	}
+	err = Manage().setOpts(withContext(ctx)).run(args...)
+	if err == nil {
+		// Operations right after the import may otherwise fail with "object not ready".
+		return waitForRegistration(ctx, name, RegistrationTimeout)
+	}
+	if ctx.Err() != nil {
+		err = fmt.Errorf("import of '%s' aborted: %w", path, ctx.Err())
+	}
+	if existed || cfg.noCleanup {
+		return err
+	}
+	if _, perr := machineProps(name); perr == ErrMachineNotExist {
+		return err
+	}
+	if uerr := Manage().run("unregistervm", name, "--delete"); uerr != nil {
+		return fmt.Errorf("%w (failed removing partially imported machine '%s': %v)", err, name, uerr)
+	}
+	return err
+}
+
+// waitForRegistration polls 'list vms' until the machine is listed, ctx is
+// done or the timeout expires.
+func waitForRegistration(ctx context.Context, name string, timeout time.Duration) error {
+	err := waitOptions(timeout, nil).poll(func() (bool, error) {
+		if err := ctx.Err(); err != nil {
+			return false, err
+		}
+		out, err := Manage().runOut("list", "vms")
+		if err != nil {
+			return false, err
+		}
+		s := bufio.NewScanner(strings.NewReader(out))
+		for s.Scan() {
+			if res := reVMNameUUID.FindStringSubmatch(s.Text()); res != nil && res[1] == name {
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+	if err == ErrStateTimeout {
+		return fmt.Errorf("machine '%s' not registered after import: %w", name, err)
+	}
+	return err
+}
+
+// fileDigests holds the digests of a file for each manifest algorithm.
+type fileDigests map[string]string
+
+func digest(r io.Reader) (fileDigests, error) {
+	hashes := map[string]hash.Hash{"SHA1": sha1.New(), "SHA256": sha256.New()} // #nosec
+	w := io.MultiWriter(hashes["SHA1"], hashes["SHA256"])
+	if _, err := io.Copy(w, r); err != nil {
+		return nil, err
+	}
+	d := fileDigests{}
+	for algo, h := range hashes {
+		d[algo] = hex.EncodeToString(h.Sum(nil))
+	}
+	return d, nil
+}
+
+// VerifyOVFManifest checks the SHA1/SHA256 digests listed in the manifest
+// (.mf) of the OVA archive or OVF descriptor at the given path against the
+// actual files. It returns an error describing the first mismatch, or when
+// there is no manifest at all.
+func VerifyOVFManifest(path string) error {
+	digests := map[string]fileDigests{}
+	var manifest string
+
+	if strings.EqualFold(filepath.Ext(path), ".ova") {
+		f, err := os.Open(path) // #nosec
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		tr := tar.NewReader(f)
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return fmt.Errorf("reading '%s': %w", path, err)
+			}
+			if strings.EqualFold(filepath.Ext(hdr.Name), ".mf") {
+				b, err := ioutil.ReadAll(tr)
+				if err != nil {
+					return err
+				}
+				manifest = string(b)
+				continue
+			}
+			d, err := digest(tr)
+			if err != nil {
+				return fmt.Errorf("reading '%s' from '%s': %w", hdr.Name, path, err)
+			}
+			digests[hdr.Name] = d
+		}
+	} else {
+		mfPath := strings.TrimSuffix(path, filepath.Ext(path)) + ".mf"
+		b, err := ioutil.ReadFile(mfPath) // #nosec
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		manifest = string(b)
+	}
+	if manifest == "" {
+		return fmt.Errorf("no manifest found for '%s'", path)
+	}
+
+	s := bufio.NewScanner(strings.NewReader(manifest))
+	for s.Scan() {
+		res := reManifestLine.FindStringSubmatch(strings.TrimSpace(s.Text()))
+		if res == nil {
+			continue
+		}
+		algo, name, expected := res[1], res[2], strings.ToLower(res[3])
+		d, ok := digests[name]
+		if !ok && !strings.EqualFold(filepath.Ext(path), ".ova") {
+			f, err := os.Open(filepath.Join(filepath.Dir(path), name)) // #nosec
+			if err != nil {
+				return fmt.Errorf("manifest of '%s' lists '%s': %w", path, name, err)
+			}
+			d, err = digest(f)
+			_ = f.Close()
+			if err != nil {
+				return err
+			}
+			ok = true
+		}
+		if !ok {
+			return fmt.Errorf("manifest of '%s' lists '%s' which is missing from the archive", path, name)
+		}
+		if d[algo] != expected {
+			return fmt.Errorf("%s digest mismatch for '%s' in '%s': expected %s, got %s", algo, name, path, expected, d[algo])
+		}
+	}
+	return s.Err()
 }
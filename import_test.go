@@ -0,0 +1,167 @@
+package virtualbox
+
+import (
+	"archive/tar"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func writeTestOVA(t *testing.T, dir string, files map[string]string, manifest string) string {
+	path := filepath.Join(dir, "test.ova")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	tw := tar.NewWriter(f)
+	add := func(name, content string) {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content))}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	add("test.ovf", files["test.ovf"])
+	add("test.mf", manifest)
+	add("test-disk001.vmdk", files["test-disk001.vmdk"])
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestVerifyOVFManifest(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-virtualbox-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{"test.ovf": "<Envelope/>", "test-disk001.vmdk": "disk content"}
+	var mf strings.Builder
+	for _, name := range []string{"test.ovf", "test-disk001.vmdk"} {
+		fmt.Fprintf(&mf, "SHA256(%s)= %x\n", name, sha256.Sum256([]byte(files[name])))
+	}
+
+	ova := writeTestOVA(t, dir, files, mf.String())
+	if err := VerifyOVFManifest(ova); err != nil {
+		t.Fatal(err)
+	}
+
+	files["test-disk001.vmdk"] = "truncated"
+	ova = writeTestOVA(t, dir, files, mf.String())
+	err = VerifyOVFManifest(ova)
+	if err == nil || !strings.Contains(err.Error(), "digest mismatch for 'test-disk001.vmdk'") {
+		t.Fatalf("expected a digest mismatch, got %v", err)
+	}
+	t.Log(err)
+}
+
+func TestImportOVFContextCancelled(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		const name = "imported"
+		notFound := "VBoxManage: error: Could not find a registered machine named 'imported'\n"
+		vmInfoOut := ReadTestData("vboxmanage-showvminfo-1.out")
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		registered := false
+		gomock.InOrder(
+			ManageMock.EXPECT().runOutErr("showvminfo", name, "--machinereadable").Return("", notFound, errors.New("exit status 1")).Times(1),
+			ManageMock.EXPECT().setOpts(gomock.Any()).Return(ManageMock).Times(1),
+			ManageMock.EXPECT().run("import", "test.ova", "--vsys", "0", "--vmname", name).
+				DoAndReturn(func(args ...string) error {
+					// The machine is registered before its disks are copied.
+					registered = true
+					cancel()
+					return errors.New("signal: killed")
+				}).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", name, "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+			ManageMock.EXPECT().run("unregistervm", name, "--delete").
+				DoAndReturn(func(args ...string) error { registered = false; return nil }).Times(1),
+		)
+		err := ImportOVFContext(ctx, "test.ova", 0, name)
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected a cancellation error, got %v", err)
+		}
+		if registered {
+			t.Fatal("partially imported machine is still registered")
+		}
+	}
+
+	Teardown()
+}
+
+func TestImportOVFProbeFailure(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		locked := "VBoxManage: error: The object is not ready\n"
+		ManageMock.EXPECT().runOutErr("showvminfo", "go-virtualbox", "--machinereadable").Return("", locked, errors.New("exit status 1")).Times(1)
+		ManageMock.EXPECT().run("unregistervm", gomock.Any()).Times(0)
+		if err := ImportOVF("test.ova", 0, "go-virtualbox"); err == nil || err == ErrMachineNotExist {
+			t.Fatalf("expected the showvminfo error, got %v", err)
+		}
+	}
+
+	Teardown()
+}
+
+func TestImportOVFWaitsForRegistration(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		notFound := "VBoxManage: error: Could not find a registered machine named 'go-virtualbox'\n"
+		gomock.InOrder(
+			ManageMock.EXPECT().runOutErr("showvminfo", "go-virtualbox", "--machinereadable").Return("", notFound, errors.New("exit status 1")).Times(1),
+			ManageMock.EXPECT().setOpts(gomock.Any()).Return(ManageMock).Times(1),
+			ManageMock.EXPECT().run("import", "test.ova", "--vsys", "0", "--vmname", "go-virtualbox").Return(nil).Times(1),
+			ManageMock.EXPECT().runOut("list", "vms").Return(`"Ubuntu" {2e16b1fc-675d-4a7a-a9a1-e89a8bde7874}`, nil).Times(1),
+			ManageMock.EXPECT().runOut("list", "vms").Return(ReadTestData("vboxmanage-list-vms-1.out"), nil).Times(1),
+		)
+		if err := ImportOVF("test.ova", 0, "go-virtualbox"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	Teardown()
+}
+
+func TestImportOVFBaseFolder(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		dir, err := ioutil.TempDir("", "go-virtualbox-test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+		notFound := "VBoxManage: error: Could not find a registered machine named 'go-virtualbox'\n"
+		gomock.InOrder(
+			ManageMock.EXPECT().runOutErr("showvminfo", "go-virtualbox", "--machinereadable").Return("", notFound, errors.New("exit status 1")).Times(1),
+			ManageMock.EXPECT().setOpts(gomock.Any()).Return(ManageMock).Times(1),
+			ManageMock.EXPECT().run("import", "test.ova", "--vsys", "0", "--vmname", "go-virtualbox", "--basefolder", dir).Return(nil).Times(1),
+			ManageMock.EXPECT().runOut("list", "vms").Return(ReadTestData("vboxmanage-list-vms-1.out"), nil).Times(1),
+		)
+		if err := ImportOVF("test.ova", 0, "go-virtualbox", ImportBaseFolder(dir)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := ImportOVF("test.ova", 0, "go-virtualbox", ImportBaseFolder(filepath.Join("does", "not", "exist"))); err == nil {
+		t.Fatal("expected an error for a missing base folder")
+	}
+
+	Teardown()
+}
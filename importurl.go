@@ -0,0 +1,74 @@
+package virtualbox
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+)
+
+// ImportOVFFromURL downloads the OVA archive at the given URL and imports it,
+// see ImportOVFFromURLContext.
+func ImportOVFFromURL(url string, vsys int, name string, opts ...ImportOption) error {
+	return ImportOVFFromURLContext(context.Background(), url, vsys, name, opts...)
+}
+
+// ImportOVFFromURLContext downloads the OVA archive at the given HTTP(S) URL
+// to a temporary file, which is always removed afterwards, and imports it
+// with ImportOVFContext, the options included, e.g. ImportChecksum. Both the
+// download and the import stop when ctx is done. An OVF descriptor cannot be
+// imported from a URL, since its disks are separate files.
+func ImportOVFFromURLContext(ctx context.Context, rawurl string, vsys int, name string, opts ...ImportOption) error {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidArgument, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("%w: unsupported URL scheme '%s'", ErrInvalidArgument, u.Scheme)
+	}
+	if strings.EqualFold(path.Ext(u.Path), ".ovf") {
+		return fmt.Errorf("%w: only OVA archives can be imported from a URL", ErrInvalidArgument)
+	}
+	if err := ValidateMachineName(name); err != nil {
+		return err
+	}
+
+	f, err := ioutil.TempFile("", "go-virtualbox-import-*.ova")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	err = download(ctx, u.String(), f)
+	if cerr := f.Close(); cerr != nil && err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	return ImportOVFContext(ctx, f.Name(), vsys, name, opts...)
+}
+
+// download writes the body of the given URL to w.
+func download(ctx context.Context, url string, w io.Writer) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("downloading '%s': %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("downloading '%s': %s", url, resp.Status)
+	}
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		return fmt.Errorf("downloading '%s': %w", url, err)
+	}
+	return nil
+}
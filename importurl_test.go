@@ -0,0 +1,72 @@
+package virtualbox
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestImportOVFFromURL(t *testing.T) {
+	Setup(t)
+
+	body := "fake appliance"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/ubuntu.ova" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, body)
+	}))
+	defer srv.Close()
+	sum := sha256.Sum256([]byte(body))
+
+	if err := ImportOVFFromURL(srv.URL+"/ubuntu.ovf", 0, "go-virtualbox"); !errors.Is(err, ErrInvalidArgument) {
+		t.Fatalf("expected ErrInvalidArgument for an OVF descriptor, got %v", err)
+	}
+	if err := ImportOVFFromURL(srv.URL+"/missing.ova", 0, "go-virtualbox"); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Fatalf("expected a 404 error, got %v", err)
+	}
+	if err := ImportOVFFromURL(srv.URL+"/ubuntu.ova", 0, "go-virtualbox", ImportChecksum("sha256", strings.Repeat("0", 64))); err == nil ||
+		!strings.Contains(err.Error(), "digest mismatch") {
+		t.Fatalf("expected a digest mismatch, got %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := ImportOVFFromURLContext(ctx, srv.URL+"/ubuntu.ova", 0, "go-virtualbox"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the download to be cancelled, got %v", err)
+	}
+	if ManageMock != nil {
+		var imported string
+		notFound := "VBoxManage: error: Could not find a registered machine named 'go-virtualbox'\n"
+		gomock.InOrder(
+			ManageMock.EXPECT().runOutErr("showvminfo", "go-virtualbox", "--machinereadable").Return("", notFound, errors.New("exit status 1")).Times(1),
+			ManageMock.EXPECT().setOpts(gomock.Any()).Return(ManageMock).Times(1),
+			ManageMock.EXPECT().run("import", gomock.Any(), "--vsys", "0", "--vmname", "go-virtualbox").
+				DoAndReturn(func(args ...string) error {
+					imported = args[1]
+					return nil
+				}).Times(1),
+			ManageMock.EXPECT().runOut("list", "vms").Return(ReadTestData("vboxmanage-list-vms-1.out"), nil).Times(1),
+		)
+		if err := ImportOVFFromURL(srv.URL+"/ubuntu.ova", 0, "go-virtualbox", ImportChecksum("SHA256", hex.EncodeToString(sum[:]))); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasSuffix(imported, ".ova") {
+			t.Fatalf("expected an .ova file to be imported, got '%s'", imported)
+		}
+		if _, err := os.Stat(imported); !os.IsNotExist(err) {
+			t.Fatalf("expected the downloaded file to be removed, got %v", err)
+		}
+	}
+
+	Teardown()
+}
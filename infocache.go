@@ -0,0 +1,95 @@
+package virtualbox
+
+import (
+	"sync"
+	"time"
+)
+
+// MachineInfoTTL is how long the showvminfo output of a machine is reused by
+// the functions reading the machine information, such as GetMachine, GetNIC or
+// ListAttachments. It is disabled by default; a short TTL such as 200ms saves a
+// subprocess per read in bursts of reads. The cache is dropped whenever a
+// command which may change a machine is run, or by InvalidateMachineInfo.
+var MachineInfoTTL time.Duration
+
+var machineInfo struct {
+	sync.Mutex
+	entries map[string]machineInfoEntry // keyed by machine UUID
+}
+
+type machineInfoEntry struct {
+	at    time.Time
+	props map[string]string
+}
+
+// readOnlyCommands are the VBoxManage commands which never change a machine.
+var readOnlyCommands = map[string]bool{
+	"--version":      true,
+	"getextradata":   true,
+	"list":           true,
+	"showmediuminfo": true,
+	"showvminfo":     true,
+}
+
+// cachedMachineProps returns the cached properties of the machine, given by
+// name or UUID, if fresh. The returned map must not be modified.
+func cachedMachineProps(ref string) (map[string]string, bool) {
+	if MachineInfoTTL <= 0 {
+		return nil, false
+	}
+	machineInfo.Lock()
+	defer machineInfo.Unlock()
+	e, ok := machineInfo.entries[ref]
+	if !ok {
+		for _, c := range machineInfo.entries {
+			if c.props["name"] == ref {
+				e, ok = c, true
+				break
+			}
+		}
+	}
+	if !ok || time.Since(e.at) >= MachineInfoTTL {
+		return nil, false
+	}
+	return e.props, true
+}
+
+// cacheMachineProps caches the properties of a machine under its UUID, so
+// that reads by name and by UUID share them and a rename does not leave a
+// stale entry behind.
+func cacheMachineProps(props map[string]string) {
+	if MachineInfoTTL <= 0 || props["UUID"] == "" {
+		return
+	}
+	machineInfo.Lock()
+	defer machineInfo.Unlock()
+	if machineInfo.entries == nil {
+		machineInfo.entries = map[string]machineInfoEntry{}
+	}
+	machineInfo.entries[props["UUID"]] = machineInfoEntry{at: time.Now(), props: props}
+}
+
+// InvalidateMachineInfo drops the cached information of the machine, given by
+// name or UUID, or of all the machines if vm is empty.
+func InvalidateMachineInfo(vm string) {
+	machineInfo.Lock()
+	defer machineInfo.Unlock()
+	if vm == "" {
+		machineInfo.entries = nil
+		return
+	}
+	for uuid, e := range machineInfo.entries {
+		if uuid == vm || e.props["name"] == vm {
+			delete(machineInfo.entries, uuid)
+		}
+	}
+}
+
+// invalidateAfter drops the whole cache after a command which may have changed
+// a machine, whichever name or UUID it used.
+func invalidateAfter(args []string) {
+	if len(args) > 0 && readOnlyCommands[args[0]] {
+		return
+	}
+	InvalidateMachineInfo("")
+}
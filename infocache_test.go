@@ -0,0 +1,77 @@
+package virtualbox
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMachineInfoCache(t *testing.T) {
+	Setup(t)
+
+	MachineInfoTTL = time.Minute
+	defer func() {
+		MachineInfoTTL = 0
+		InvalidateMachineInfo("")
+	}()
+	if ManageMock != nil {
+		vm := "go-virtualbox" // cached entries are found by the name or UUID read
+		vmInfoOut := ReadTestData("vboxmanage-showvminfo-1.out")
+		ManageMock.EXPECT().runOutErr("showvminfo", vm, "--machinereadable").Return(vmInfoOut, "", nil).Times(3)
+
+		// A burst of reads costs a single showvminfo.
+		if _, err := GetMachine(vm); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := GetNIC(vm, 1); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ListAttachments(vm); err != nil {
+			t.Fatal(err)
+		}
+
+		InvalidateMachineInfo(vm)
+		if _, err := GetMachine(vm); err != nil {
+			t.Fatal(err)
+		}
+		invalidateAfter([]string{"showvminfo", vm})
+		if _, err := GetMachine(vm); err != nil {
+			t.Fatal(err)
+		}
+		invalidateAfter([]string{"modifyvm", vm, "--cpus", "2"})
+		if _, err := GetMachine(vm); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	Teardown()
+}
+
+func TestInvalidateMachineInfoByName(t *testing.T) {
+	Setup(t)
+
+	MachineInfoTTL = time.Minute
+	defer func() {
+		MachineInfoTTL = 0
+		InvalidateMachineInfo("")
+	}()
+	if ManageMock != nil {
+		vmInfoOut := ReadTestData("vboxmanage-showvminfo-1.out")
+		uuid := "37f5d336-bf07-48dd-947c-37e6a56420a7"
+		ManageMock.EXPECT().runOutErr("showvminfo", uuid, "--machinereadable").Return(vmInfoOut, "", nil).Times(2)
+
+		name, id, err := ResolveMachine(uuid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if name != "go-virtualbox" || id != uuid {
+			t.Fatalf("unexpected name '%s' and UUID '%s'", name, id)
+		}
+		// Read by UUID, dropped by name.
+		InvalidateMachineInfo(name)
+		if _, err := GetMachine(uuid); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	Teardown()
+}
@@ -0,0 +1,175 @@
+package virtualbox
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// HIDType represents the emulated hardware of a keyboard or pointing device.
+type HIDType string
+
+const (
+	// HIDPS2 when the device is emulated on the PS/2 bus.
+	HIDPS2 = HIDType("ps2")
+	// HIDUSB when the device is emulated as a USB keyboard or relative mouse.
+	HIDUSB = HIDType("usb")
+	// HIDUSBTablet when the pointing device is an absolute USB tablet.
+	HIDUSBTablet = HIDType("usbtablet")
+	// HIDUSBMultiTouch when the pointing device is a USB multi-touch screen.
+	HIDUSBMultiTouch = HIDType("usbmultitouch")
+)
+
+// hidTypeFromInfo maps the hidkeyboard/hidpointing showvminfo values, e.g.
+// "ps2kbd" or "usbmouse", to a HIDType.
+func hidTypeFromInfo(val string) HIDType {
+	switch val {
+	case "ps2kbd", "ps2mouse":
+		return HIDPS2
+	case "usbkbd", "usbmouse":
+		return HIDUSB
+	}
+	return HIDType(strings.ToLower(val))
+}
+
+// SetInputDevices sets the emulated keyboard and pointing device of the
+// machine, which must not be running. The keyboard can only be HIDPS2 or HIDUSB.
+func SetInputDevices(vm string, keyboard, mouse HIDType) error {
+	switch keyboard {
+	case HIDPS2, HIDUSB:
+	default:
+		return fmt.Errorf("invalid keyboard type: '%s'", keyboard)
+	}
+	switch mouse {
+	case HIDPS2, HIDUSB, HIDUSBTablet, HIDUSBMultiTouch:
+	default:
+		return fmt.Errorf("invalid mouse type: '%s'", mouse)
+	}
+
+	if err := assertMutable(vm); err != nil {
+		return err
+	}
+	return Manage().run("modifyvm", vm, "--keyboard", string(keyboard), "--mouse", string(mouse))
+}
+
+// SetUSBCardReader toggles the emulated USB card reader of the machine, which
+// must not be running.
+func SetUSBCardReader(vm string, on bool) error {
+	if err := assertMutable(vm); err != nil {
+		return err
+	}
+	return Manage().run("modifyvm", vm, "--usbcardreader", bool2string(on))
+}
+
+// MouseButtons is a bitmask of the pressed mouse buttons.
+type MouseButtons int
+
+const (
+	// MouseLeft is the left mouse button.
+	MouseLeft MouseButtons = 1 << iota
+	// MouseRight is the right mouse button.
+	MouseRight
+	// MouseMiddle is the middle mouse button.
+	MouseMiddle
+)
+
+// SendMouseEvent moves the mouse of the running machine by dx, dy pixels and
+// its wheels by dz (vertical) and dw (horizontal) steps, with the given
+// buttons held. Relative events need a relative pointing device (HIDPS2 or
+// HIDUSB); they are subject to the guest pointer acceleration.
+func SendMouseEvent(vm string, dx, dy, dz, dw int, buttons MouseButtons) error {
+	return sendMouseEvent(vm, "putmouseevent", dx, dy, dz, dw, buttons)
+}
+
+// SendMouseEventAbs moves the mouse of the running machine to x, y, in pixels
+// from the top-left corner of the guest screen, and its wheels by dz and dw
+// steps, with the given buttons held. Absolute events need an absolute
+// pointing device, i.e. HIDUSBTablet or HIDUSBMultiTouch.
+func SendMouseEventAbs(vm string, x, y, dz, dw int, buttons MouseButtons) error {
+	return sendMouseEvent(vm, "putmouseeventabs", x, y, dz, dw, buttons)
+}
+
+// ClickAt clicks the given buttons at x, y on the guest screen of the running
+// machine, by pressing and releasing them there. See SendMouseEventAbs.
+func ClickAt(vm string, x, y int, buttons MouseButtons) error {
+	if err := SendMouseEventAbs(vm, x, y, 0, 0, buttons); err != nil {
+		return err
+	}
+	return SendMouseEventAbs(vm, x, y, 0, 0, 0)
+}
+
+func sendMouseEvent(vm, event string, x, y, dz, dw int, buttons MouseButtons) error {
+	if buttons < 0 || buttons > MouseLeft|MouseRight|MouseMiddle {
+		return fmt.Errorf("invalid mouse buttons: %d", buttons)
+	}
+	r, err := isRunning(vm)
+	if err != nil {
+		return err
+	}
+	if !r {
+		return ErrMachineNotRunning
+	}
+	return Manage().run("controlvm", vm, event,
+		strconv.Itoa(x), strconv.Itoa(y), strconv.Itoa(dz), strconv.Itoa(dw), strconv.Itoa(int(buttons)))
+}
+
+// EnableTouchInput makes the pointing device of the machine a USB multi-touch
+// screen, enabling the USB 1.1 controller it needs when the machine has no
+// USB controller. The keyboard is left as is. The machine must not be
+// running.
+func EnableTouchInput(vm string) error {
+	props, err := machineProps(vm)
+	if err != nil {
+		return err
+	}
+	if !hasUSBController(props) {
+		if err := SetUSBController(vm, USBOHCI, true); err != nil {
+			return err
+		}
+	}
+	keyboard := hidTypeFromInfo(props["hidkeyboard"])
+	if keyboard != HIDUSB {
+		keyboard = HIDPS2
+	}
+	return SetInputDevices(vm, keyboard, HIDUSBMultiTouch)
+}
+
+// GetInputIntegration tells whether the mouse pointer of the machine is
+// integrated, i.e. follows absolute positions as sent by SendMouseEventAbs,
+// rather than relative moves. Absolute pointing devices (HIDUSBTablet,
+// HIDUSBMultiTouch) always are; relative ones only once the Guest Additions
+// run in the guest. For those, it returns an error wrapping
+// ErrGuestAdditionsRequired when the Guest Additions do not report in.
+func GetInputIntegration(vm string) (mouseIntegrated bool, err error) {
+	props, err := machineProps(vm)
+	if err != nil {
+		return false, err
+	}
+	switch hidTypeFromInfo(props["hidpointing"]) {
+	case HIDUSBTablet, HIDUSBMultiTouch:
+		return true, nil
+	}
+	if ready, _ := (GuestAdditionsProbe{}).Ready(vm); !ready {
+		return false, fmt.Errorf("mouse integration of '%s' is unknown: %w", vm, ErrGuestAdditionsRequired)
+	}
+	return true, nil
+}
+
+// MouseEventFunc is the signature of SendMouseEvent and SendMouseEventAbs.
+type MouseEventFunc func(vm string, x, y, dz, dw int, buttons MouseButtons) error
+
+// MouseEventFor picks SendMouseEventAbs when the mouse pointer of the machine
+// is integrated, see GetInputIntegration, and SendMouseEvent otherwise, or
+// when the Guest Additions do not tell. absolute reports which one it picked,
+// i.e. whether x, y are positions or moves.
+func MouseEventFor(vm string) (send MouseEventFunc, absolute bool, err error) {
+	integrated, err := GetInputIntegration(vm)
+	if err != nil && !errors.Is(err, ErrGuestAdditionsRequired) {
+		return nil, false, err
+	}
+	if integrated {
+		return SendMouseEventAbs, true, nil
+	}
+	return SendMouseEvent, false, nil
+}
@@ -0,0 +1,107 @@
+package virtualbox
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestSetInputDevicesNotMutable(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		vmInfoOut := ReadTestData("vboxmanage-showvminfo-1.out")
+		running := strings.Replace(vmInfoOut, `VMState="saved"`, `VMState="running"`, 1)
+		locked := strings.Replace(vmInfoOut, `VMState="saved"`, `VMState="poweroff"`+"\nSessionName=\"GUI/Qt\"", 1)
+		ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(running, "", nil).Times(1)
+		if err := SetInputDevices(VM, HIDUSB, HIDUSBTablet); !errors.Is(err, ErrMachineRunning) {
+			t.Fatalf("expected ErrMachineRunning, got %v", err)
+		}
+		ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(locked, "", nil).Times(1)
+		if err := SetUSBCardReader(VM, true); !errors.Is(err, ErrMachineLocked) {
+			t.Fatalf("expected ErrMachineLocked, got %v", err)
+		}
+	}
+
+	Teardown()
+}
+
+func TestClickAt(t *testing.T) {
+	Setup(t)
+
+	running.vms = nil
+	if ManageMock != nil {
+		listRunningVmsOut := ReadTestData("vboxmanage-list-vms-1.out")
+		gomock.InOrder(
+			ManageMock.EXPECT().runOut("list", "runningvms").Return(listRunningVmsOut, nil).Times(1),
+			ManageMock.EXPECT().run("controlvm", "Ubuntu", "putmouseeventabs", "10", "20", "0", "0", "1").Return(nil).Times(1),
+			ManageMock.EXPECT().run("controlvm", "Ubuntu", "putmouseeventabs", "10", "20", "0", "0", "0").Return(nil).Times(1),
+		)
+		if err := ClickAt("Ubuntu", 10, 20, MouseLeft); err != nil {
+			t.Fatal(err)
+		}
+		if err := SendMouseEvent("Stopped", 1, 1, 0, 0, 0); err != ErrMachineNotRunning {
+			t.Fatalf("expected ErrMachineNotRunning, got %v", err)
+		}
+		if err := SendMouseEvent("Ubuntu", 1, 1, 0, 0, 8); err == nil {
+			t.Fatal("expected an error for invalid buttons")
+		}
+	}
+
+	Teardown()
+}
+
+func TestEnableTouchInput(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		vmInfoOut := ReadTestData("vboxmanage-showvminfo-poweroff.out")
+		withUSB := strings.Replace(vmInfoOut, `xhci="off"`, `xhci="on"`, 1)
+		gomock.InOrder(
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(2),
+			ManageMock.EXPECT().run("modifyvm", VM, "--usbohci", "on").Return(nil).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+			ManageMock.EXPECT().run("modifyvm", VM, "--keyboard", "ps2", "--mouse", "usbmultitouch").Return(nil).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(withUSB, "", nil).Times(2),
+			ManageMock.EXPECT().run("modifyvm", VM, "--keyboard", "ps2", "--mouse", "usbmultitouch").Return(nil).Times(1),
+		)
+		if err := EnableTouchInput(VM); err != nil {
+			t.Fatal(err)
+		}
+		if err := EnableTouchInput(VM); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	Teardown()
+}
+
+func TestGetInputIntegration(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		vmInfoOut := ReadTestData("vboxmanage-showvminfo-1.out")
+		tabletOut := strings.Replace(vmInfoOut, `hidpointing="ps2mouse"`, `hidpointing="usbtablet"`, 1)
+		ManageMock.EXPECT().isGuest().Return(false).AnyTimes()
+		gomock.InOrder(
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(tabletOut, "", nil).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+			ManageMock.EXPECT().runOut("guestproperty", "get", VM, "/VirtualBox/GuestAdd/Version").Return("Value: 6.1.16", nil).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+			ManageMock.EXPECT().runOut("guestproperty", "get", VM, "/VirtualBox/GuestAdd/Version").Return("No value set!", nil).Times(1),
+		)
+		if ok, err := GetInputIntegration(VM); err != nil || !ok {
+			t.Fatalf("expected a tablet to be integrated, got %v (%v)", ok, err)
+		}
+		if ok, err := GetInputIntegration(VM); err != nil || !ok {
+			t.Fatalf("expected the Guest Additions to integrate the mouse, got %v (%v)", ok, err)
+		}
+		if _, abs, err := MouseEventFor(VM); err != nil || abs {
+			t.Fatalf("expected relative events without the Guest Additions, got %v (%v)", abs, err)
+		}
+	}
+
+	Teardown()
+}
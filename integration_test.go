@@ -0,0 +1,93 @@
+//go:build integration
+// +build integration
+
+package virtualbox
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+// TestIntegrationLifecycle runs the real VBoxManage through the life of a tiny
+// disk-less machine. Run it with:
+//
+//	go test -tags integration -run Integration -v .
+func TestIntegrationLifecycle(t *testing.T) {
+	manage = nil // drop any mock left by the unit tests
+	defer func() { manage = nil }()
+	if _, err := LookupVBoxManage(); err != nil {
+		t.Skip(err)
+	}
+	if _, err := Version(); errors.Is(err, ErrVirtualBoxNotInstalled) || err == ErrCommandNotFound {
+		t.Skip(err)
+	} else if err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "go-virtualbox-integration")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	name := fmt.Sprintf("go-virtualbox-it-%d", time.Now().UnixNano())
+	m, err := CreateMachine(name, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := m.Refresh(); err != nil {
+			t.Errorf("refreshing '%s': %v", name, err)
+		}
+		if err := m.Poweroff(); err != nil {
+			t.Errorf("powering off '%s': %v", name, err)
+		}
+		if err := WaitUntilState(name, Poweroff, time.Minute); err != nil {
+			t.Errorf("waiting for '%s' to power off: %v", name, err)
+		}
+		if err := Manage().run("unregistervm", name, "--delete"); err != nil {
+			t.Errorf("deleting '%s': %v", name, err)
+		}
+	}()
+
+	m.OSType = "Other"
+	m.CPUs = 1
+	m.Memory = 32
+	m.VRAM = 8
+	m.Flag = ACPI
+	if err := m.Modify(); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+	if m.Memory != 32 || m.CPUs != 1 {
+		t.Fatalf("settings not applied: %+v", m)
+	}
+
+	// Without a disk, the BIOS just waits for a bootable medium.
+	if err := m.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if err := WaitUntilState(name, Running, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := TakeSnapshot(name, "integration", "taken by the integration tests"); err != nil {
+		t.Fatal(err)
+	}
+	snapshots, err := ListSnapshots(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshots) != 1 || snapshots[0].Name != "integration" || !snapshots[0].Current {
+		t.Fatalf("unexpected snapshots: %+v", snapshots)
+	}
+	if err := DeleteSnapshot(name, "integration"); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -3,6 +3,7 @@ package virtualbox
 import (
 	"bufio"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"path/filepath"
@@ -62,21 +63,72 @@ func (f Flag) Get(o Flag) string {
 	return bool2string(f&o == o)
 }
 
+// flagNames are the VBoxManage option names of the flags, in bit order.
+var flagNames = []string{
+	"acpi", "ioapic", "rtcuseutc", "cpuhotplug", "pae", "longmode", "hpet",
+	"hwvirtex", "triplefaultreset", "nestedpaging", "largepages", "vtxvpid",
+	"vtxux", "accelerate3d",
+}
+
+// MarshalJSON encodes the flag as the list of the set flag names, e.g.
+// ["acpi","ioapic"].
+func (f Flag) MarshalJSON() ([]byte, error) {
+	names := []string{}
+	for i, name := range flagNames {
+		if f&(1<<uint(i)) != 0 {
+			names = append(names, name)
+		}
+	}
+	return json.Marshal(names)
+}
+
+// UnmarshalJSON decodes a list of flag names, as encoded by MarshalJSON.
+func (f *Flag) UnmarshalJSON(b []byte) error {
+	var names []string
+	if err := json.Unmarshal(b, &names); err != nil {
+		return err
+	}
+	*f = 0
+	for _, name := range names {
+		i := 0
+		for i < len(flagNames) && flagNames[i] != name {
+			i++
+		}
+		if i == len(flagNames) {
+			return fmt.Errorf("unknown flag: '%s'", name)
+		}
+		*f |= 1 << uint(i)
+	}
+	return nil
+}
+
 // Machine information.
 type Machine struct {
-	Name       string
-	Firmware   string
-	UUID       string
-	State      MachineState
-	CPUs       uint
-	Memory     uint // main memory (in MB)
-	VRAM       uint // video memory (in MB)
-	CfgFile    string
-	BaseFolder string
-	OSType     string
-	Flag       Flag
-	BootOrder  []string // max 4 slots, each in {none|floppy|dvd|disk|net}
-	NICs       []NIC
+	Name              string             `json:"name"`
+	Firmware          string             `json:"firmware"`
+	UUID              string             `json:"uuid"`
+	State             MachineState       `json:"state"`
+	CPUs              uint               `json:"cpus"`
+	Memory            uint               `json:"memory"` // main memory (in MB)
+	VRAM              uint               `json:"vram"`   // video memory (in MB)
+	CfgFile           string             `json:"cfg_file"`
+	BaseFolder        string             `json:"base_folder"`
+	OSType            string             `json:"os_type"`
+	OSTypeDescription string             `json:"os_type_description"` // as reported by showvminfo, e.g. "Ubuntu (64-bit)"
+	Flag              Flag               `json:"flag"`
+	BootOrder         []string           `json:"boot_order"` // max 4 slots, each in {none|floppy|dvd|disk|net}
+	NICs              []NIC              `json:"nics"`
+	Keyboard          HIDType            `json:"keyboard"`
+	Mouse             HIDType            `json:"mouse"`
+	Description       string             `json:"description"`
+	Groups            []string           `json:"groups"` // group paths, e.g. "/" or "/prod/web"
+	Chipset           ChipsetType        `json:"chipset"`
+	SnapshotFolder    string             `json:"snapshot_folder"`
+	BIOSTimeOffset    time.Duration      `json:"bios_time_offset"` // of the guest clock from the host one
+	TPM               TPMType            `json:"tpm,omitempty"`    // when supported by VirtualBox
+	IOMMU             IOMMUType          `json:"iommu,omitempty"`  // when supported by VirtualBox
+	Graphics          GraphicsController `json:"graphics,omitempty"`
+	SharedFolders     []SharedFolder     `json:"shared_folders,omitempty"`
 }
 
 // New creates a new machine.
@@ -122,6 +174,9 @@ func (m *Machine) Start() error {
 
 // DisconnectSerialPort sets given serial port to disconnected.
 func (m *Machine) DisconnectSerialPort(portNumber int) error {
+	if err := assertMutable(m.Name); err != nil {
+		return err
+	}
 	return Manage().run("modifyvm", m.Name, fmt.Sprintf("--uartmode%d", portNumber), "disconnected")
 }
 
@@ -212,10 +267,76 @@ func (m *Machine) Delete() error {
 	return Manage().run("unregistervm", m.Name, "--delete")
 }
 
+// ErrStateTimeout holds the error message when a machine did not reach the expected state in time.
+var ErrStateTimeout = errors.New("timed out waiting for machine state")
+
+// WaitUntilState polls the machine until it reaches the given state or the
+// timeout expires.
+func WaitUntilState(vm string, state MachineState, timeout time.Duration, opts ...WaitOptions) error {
+	// Poll by UUID, which a concurrent rename does not change.
+	_, uuid, err := ResolveMachine(vm)
+	if err != nil {
+		return err
+	}
+	return waitOptions(timeout, opts).poll(func() (bool, error) {
+		m, err := GetMachine(uuid)
+		if err != nil {
+			return false, err
+		}
+		return m.State == state, nil
+	})
+}
+
+// assertMutable checks that the settings of the machine can be changed, i.e.
+// that it is powered off and not locked by a session such as an open GUI.
+// VirtualBox refuses most settings of a machine with a saved state.
+func assertMutable(vm string) error {
+	return checkMutable(vm, false)
+}
+
+// assertMutableOrSaved is assertMutable for the few settings VirtualBox lets
+// change along with a saved state, such as the name, groups and description.
+func assertMutableOrSaved(vm string) error {
+	return checkMutable(vm, true)
+}
+
+func checkMutable(vm string, savedOK bool) error {
+	_, err := mutableProps(vm, savedOK)
+	return err
+}
+
+// mutableProps is checkMutable returning the showvminfo properties it read.
+func mutableProps(vm string, savedOK bool) (map[string]string, error) {
+	props, err := machineProps(vm)
+	if err != nil {
+		return nil, err
+	}
+	switch MachineState(props["VMState"]) {
+	case Running, Paused:
+		return nil, fmt.Errorf("cannot change the settings of '%s': %w", vm, ErrMachineRunning)
+	case Saved:
+		if !savedOK {
+			return nil, fmt.Errorf("cannot change the settings of '%s' while saved, discard the saved state first: %w", vm, ErrMachineLocked)
+		}
+	case Poweroff, Aborted:
+	default:
+		// Transient states such as starting, saving or restoring.
+		return nil, fmt.Errorf("cannot change the settings of '%s' while %s: %w", vm, props["VMState"], ErrMachineLocked)
+	}
+	if name := props["SessionName"] + props["SessionType"]; name != "" {
+		return nil, fmt.Errorf("cannot change the settings of '%s' while opened by %s: %w", vm, name, ErrMachineLocked)
+	}
+	return props, nil
+}
+
 var mutex sync.Mutex
 
-// GetMachine finds a machine by its name or UUID.
-func GetMachine(id string) (*Machine, error) {
+// machineProps reads the machine-readable showvminfo output of the machine
+// with the given name or UUID into a map, which must not be modified.
+func machineProps(id string) (map[string]string, error) {
+	if props, ok := cachedMachineProps(id); ok {
+		return props, nil
+	}
 	/* There is a strage behavior where running multiple instances of
 	'VBoxManage showvminfo' on same VM simultaneously can return an error of
 	'object is not ready (E_ACCESSDENIED)', so we sequential the operation with a mutex.
@@ -233,9 +354,19 @@ func GetMachine(id string) (*Machine, error) {
 
 	/* Read all VM info into a map */
 	propMap := make(map[string]string)
+	nicKey := ""
 	s := bufio.NewScanner(strings.NewReader(stdout))
 	for s.Scan() {
-		res := reVMInfoLine.FindStringSubmatch(s.Text())
+		line := s.Text()
+		if strings.HasPrefix(line, descriptionPrefix) {
+			// The description is the only value which can span several lines.
+			for !closingQuote(line[len(descriptionPrefix):]) && s.Scan() {
+				line += "\n" + s.Text()
+			}
+			propMap["description"] = unquoteInfoValue(strings.TrimSuffix(line[len(descriptionPrefix):], `"`))
+			continue
+		}
+		res := reVMInfoLine.FindStringSubmatch(line)
 		if res == nil {
 			continue
 		}
@@ -248,6 +379,63 @@ func GetMachine(id string) (*Machine, error) {
 			val = res[4]
 		}
 		propMap[key] = val
+		// The port forwarding rules of each NAT NIC follow its nicN key, and
+		// are numbered from 0 for every NIC: scope them to the NIC.
+		if strings.HasPrefix(key, "nic") {
+			if _, err := strconv.Atoi(key[3:]); err == nil {
+				nicKey = key
+			}
+		} else if strings.HasPrefix(key, "Forwarding(") && nicKey != "" {
+			propMap[nicKey+"."+key] = val
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	cacheMachineProps(propMap)
+	return propMap, nil
+}
+
+const descriptionPrefix = `description="`
+
+// closingQuote tells whether s ends with an unescaped double quote.
+func closingQuote(s string) bool {
+	if !strings.HasSuffix(s, `"`) {
+		return false
+	}
+	n := 0
+	for i := len(s) - 2; i >= 0 && s[i] == '\\'; i-- {
+		n++
+	}
+	return n%2 == 0
+}
+
+// unquoteInfoValue reverts the escaping of quotes, backslashes and newlines
+// done by recent VirtualBox releases in machine readable values. Older ones
+// print the value raw.
+func unquoteInfoValue(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	return strings.NewReplacer(`\\`, `\`, `\"`, `"`, `\n`, "\n").Replace(s)
+}
+
+// ResolveMachine returns the name and the UUID of the machine given by either.
+// Operations tracked across calls are better keyed by UUID, which survives
+// renames.
+func ResolveMachine(ref string) (name, uuid string, err error) {
+	props, err := machineProps(ref)
+	if err != nil {
+		return "", "", err
+	}
+	return props["name"], props["UUID"], nil
+}
+
+// GetMachine finds a machine by its name or UUID.
+func GetMachine(id string) (*Machine, error) {
+	propMap, err := machineProps(id)
+	if err != nil {
+		return nil, err
 	}
 
 	/* Extract basic info */
@@ -273,6 +461,26 @@ func GetMachine(id string) (*Machine, error) {
 	m.VRAM = uint(n)
 	m.CfgFile = propMap["CfgFile"]
 	m.BaseFolder = filepath.Dir(m.CfgFile)
+	m.Keyboard = hidTypeFromInfo(propMap["hidkeyboard"])
+	m.Mouse = hidTypeFromInfo(propMap["hidpointing"])
+	m.Description = propMap["description"]
+	m.Groups = splitGroups(propMap["groups"])
+	m.Chipset = ChipsetType(propMap["chipset"])
+	m.SnapshotFolder = propMap["SnapFldr"]
+	m.OSTypeDescription = propMap["ostype"]
+	m.TPM = tpmTypeFromInfo(propMap["tpm_type"])
+	m.IOMMU = IOMMUType(propMap["iommu"])
+	m.Graphics = GraphicsController(propMap["graphicscontroller"])
+	if propMap["hpet"] == "on" {
+		m.Flag |= HPET
+	}
+	if v, ok := propMap["biossystemtimeoffset"]; ok {
+		ms, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		m.BIOSTimeOffset = time.Duration(ms) * time.Millisecond
+	}
 
 	/* Extract NIC info */
 	for i := 1; i <= 4; i++ {
@@ -295,12 +503,17 @@ func GetMachine(id string) (*Machine, error) {
 		} else if nic.Network == NICNetBridged {
 			nic.HostInterface = propMap[fmt.Sprintf("bridgeadapter%d", i)]
 		}
+		if nic.Network == NICNetNAT {
+			if nic.PFRules, err = parseNICPFRules(propMap, i); err != nil {
+				return nil, err
+			}
+		}
 		m.NICs = append(m.NICs, nic)
 	}
 
-	if err := s.Err(); err != nil {
-		return nil, err
-	}
+	/* Extract shared folders */
+	m.SharedFolders = parseSharedFolders(propMap)
+
 	return m, nil
 }
 
@@ -335,9 +548,10 @@ func ListMachines() ([]*Machine, error) {
 }
 
 // CreateMachine creates a new machine. If basefolder is empty, use default.
+// A group qualified name, e.g. "/prod/web", creates the machine in that group.
 func CreateMachine(name, basefolder string) (*Machine, error) {
-	if name == "" {
-		return nil, fmt.Errorf("machine name is empty")
+	if err := ValidateMachineName(name); err != nil {
+		return nil, err
 	}
 
 	// Check if a machine with the given name already exists.
@@ -345,14 +559,16 @@ func CreateMachine(name, basefolder string) (*Machine, error) {
 	if err != nil {
 		return nil, err
 	}
+	_, base := splitMachineName(name)
 	for _, m := range ms {
-		if m.Name == name {
+		if m.Name == base {
 			return nil, ErrMachineExist
 		}
 	}
 
 	// Create and register the machine.
-	args := []string{"createvm", "--name", name, "--register"}
+	args := append([]string{"createvm"}, machineNameArgs("--name", "--groups", name)...)
+	args = append(args, "--register")
 	if basefolder != "" {
 		args = append(args, "--basefolder", basefolder)
 	}
@@ -360,7 +576,7 @@ func CreateMachine(name, basefolder string) (*Machine, error) {
 		return nil, err
 	}
 
-	m, err := GetMachine(name)
+	m, err := GetMachine(base)
 	if err != nil {
 		return nil, err
 	}
@@ -370,6 +586,9 @@ func CreateMachine(name, basefolder string) (*Machine, error) {
 
 // Modify changes the settings of the machine.
 func (m *Machine) Modify() error {
+	if err := assertMutable(m.Name); err != nil {
+		return err
+	}
 	args := []string{"modifyvm", m.Name,
 		"--firmware", m.Firmware,
 		"--bioslogofadein", "off",
@@ -437,22 +656,32 @@ func (m *Machine) DelNATPF(n int, name string) error {
 
 // SetNIC set the n-th NIC.
 func (m *Machine) SetNIC(n int, nic NIC) error {
-	args := []string{"modifyvm", m.Name,
+	if err := assertMutable(m.Name); err != nil {
+		return err
+	}
+	return Manage().run(append([]string{"modifyvm", m.Name}, nicArgs(n, nic)...)...)
+}
+
+// nicArgs returns the modifyvm options setting the n-th NIC.
+func nicArgs(n int, nic NIC) []string {
+	args := []string{
 		fmt.Sprintf("--nic%d", n), string(nic.Network),
 		fmt.Sprintf("--nictype%d", n), string(nic.Hardware),
 		fmt.Sprintf("--cableconnected%d", n), "on",
 	}
-
 	if nic.Network == NICNetHostonly {
 		args = append(args, fmt.Sprintf("--hostonlyadapter%d", n), nic.HostInterface)
 	} else if nic.Network == NICNetBridged {
 		args = append(args, fmt.Sprintf("--bridgeadapter%d", n), nic.HostInterface)
 	}
-	return Manage().run(args...)
+	return args
 }
 
 // AddStorageCtl adds a storage controller with the given name.
 func (m *Machine) AddStorageCtl(name string, ctl StorageController) error {
+	if err := assertMutable(m.Name); err != nil {
+		return err
+	}
 	args := []string{"storagectl", m.Name, "--name", name}
 	if ctl.SysBus != "" {
 		args = append(args, "--add", string(ctl.SysBus))
@@ -470,11 +699,20 @@ func (m *Machine) AddStorageCtl(name string, ctl StorageController) error {
 
 // DelStorageCtl deletes the storage controller with the given name.
 func (m *Machine) DelStorageCtl(name string) error {
+	if err := assertMutable(m.Name); err != nil {
+		return err
+	}
 	return Manage().run("storagectl", m.Name, "--name", name, "--remove")
 }
 
 // AttachStorage attaches a storage medium to the named storage controller.
+// Only the medium of a DVD drive can be changed while the machine runs.
 func (m *Machine) AttachStorage(ctlName string, medium StorageMedium) error {
+	if medium.DriveType != DriveDVD {
+		if err := assertMutable(m.Name); err != nil {
+			return err
+		}
+	}
 	return Manage().run("storageattach", m.Name, "--storagectl", ctlName,
 		"--port", fmt.Sprintf("%d", medium.Port),
 		"--device", fmt.Sprintf("%d", medium.Device),
@@ -509,10 +747,24 @@ func (m *Machine) DeleteExtraData(key string) error {
 	return Manage().run("setextradata", m.Name, key)
 }
 
-// CloneMachine clones the given machine name into a new one.
+// CloneMachine clones the given machine name into a new one, in the group
+// qualifying newImageName if any.
 func CloneMachine(baseImageName string, newImageName string, register bool) error {
+	if err := ValidateMachineName(newImageName); err != nil {
+		return err
+	}
+	args := append([]string{"clonevm", baseImageName}, machineNameArgs("--name", "--groups", newImageName)...)
 	if register {
-		return Manage().run("clonevm", baseImageName, "--name", newImageName, "--register")
+		args = append(args, "--register")
+	}
+	return Manage().run(args...)
+}
+
+// SetDescription sets the free-form description of the machine. The
+// description is passed as a single argument, newlines included.
+func SetDescription(vm, description string) error {
+	if err := assertMutableOrSaved(vm); err != nil {
+		return err
 	}
-	return Manage().run("clonevm", baseImageName, "--name", newImageName)
+	return Manage().run("modifyvm", vm, "--description", description)
 }
@@ -1,6 +1,10 @@
 package virtualbox
 
 import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/golang/mock/gomock"
@@ -28,3 +32,128 @@ func TestMachine(t *testing.T) {
 
 	Teardown()
 }
+
+func TestMachineDescription(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		description := "owner: infra\nticket: \"OPS-42\""
+		vmInfoOut := ReadTestData("vboxmanage-showvminfo-1.out")
+		raw := "description=\"owner: infra\nticket: \"OPS-42\"\"\n" + vmInfoOut
+		escaped := `description="owner: infra\nticket: \"OPS-42\""` + "\n" + vmInfoOut
+		gomock.InOrder(
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+			ManageMock.EXPECT().run("modifyvm", VM, "--description", description).Return(nil).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(raw, "", nil).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(escaped, "", nil).Times(1),
+		)
+		if err := SetDescription(VM, description); err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 2; i++ {
+			m, err := GetMachine(VM)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if m.Description != description {
+				t.Fatalf("expected description %q, got %q", description, m.Description)
+			}
+			if m.Name != "go-virtualbox" {
+				t.Fatalf("description parsing swallowed the following lines: %+v", m)
+			}
+		}
+	}
+
+	Teardown()
+}
+
+func TestMachineJSON(t *testing.T) {
+	m := &Machine{
+		Name:      "go-virtualbox",
+		UUID:      "37f5d336-bf07-48dd-947c-37e6a56420a7",
+		State:     Poweroff,
+		CPUs:      2,
+		Flag:      ACPI | IOAPIC | ACCELERATE3D,
+		BootOrder: []string{"disk", "dvd"},
+		NICs:      []NIC{{Network: NICNetNAT, Hardware: VirtIO, MacAddr: "080027EE1DF7"}},
+		Groups:    []string{"/"},
+	}
+	b, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `"flag":["acpi","ioapic","accelerate3d"]`) {
+		t.Fatalf("unexpected flag encoding: %s", b)
+	}
+	var mm Machine
+	if err := json.Unmarshal(b, &mm); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(&mm, m) {
+		t.Fatalf("expected %+v, got %+v", m, mm)
+	}
+	if err := json.Unmarshal([]byte(`{"flag":["warp"]}`), &mm); err == nil {
+		t.Fatal("expected an error for an unknown flag")
+	}
+}
+
+func TestMachineNICsAndSharedFolders(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		vmInfoOut := strings.Replace(ReadTestData("vboxmanage-showvminfo-1.out"),
+			`nic2="none"`,
+			"nic2=\"nat\"\nnictype2=\"virtio\"\nmacaddress2=\"080027AABBCC\"\nForwarding(0)=\"web,tcp,,8080,,80\"", 1)
+		vmInfoOut += "SharedFolderNameTransientMapping1=\"scratch\"\nSharedFolderPathTransientMapping1=\"/tmp/scratch\"\n"
+		ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(1)
+		m, err := GetMachine(VM)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(m.NICs) != 2 {
+			t.Fatalf("expected 2 NICs, got %+v", m.NICs)
+		}
+		if r, ok := m.NICs[0].PFRules["ssh"]; !ok || r.HostPort != 2222 || len(m.NICs[0].PFRules) != 1 {
+			t.Fatalf("unexpected rules of NIC 1: %+v", m.NICs[0].PFRules)
+		}
+		if r, ok := m.NICs[1].PFRules["web"]; !ok || r.GuestPort != 80 || len(m.NICs[1].PFRules) != 1 {
+			t.Fatalf("unexpected rules of NIC 2: %+v", m.NICs[1].PFRules)
+		}
+		expected := []SharedFolder{
+			{Name: "vagrant", HostPath: "/Users/fix/Desktop/GO/src/github.com/terra-farm/go-virtualbox"},
+			{Name: "scratch", HostPath: "/tmp/scratch", Transient: true},
+		}
+		if !reflect.DeepEqual(m.SharedFolders, expected) {
+			t.Fatalf("expected %+v, got %+v", expected, m.SharedFolders)
+		}
+	}
+
+	Teardown()
+}
+
+func TestAssertMutableSaved(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		saved := ReadTestData("vboxmanage-showvminfo-1.out")
+		running := strings.Replace(saved, `VMState="saved"`, `VMState="running"`, 1)
+		gomock.InOrder(
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(saved, "", nil).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(saved, "", nil).Times(1),
+			ManageMock.EXPECT().run("modifyvm", VM, "--description", "saved").Return(nil).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(running, "", nil).Times(1),
+		)
+		err := SetHPET(VM, true)
+		if !errors.Is(err, ErrMachineLocked) || !strings.Contains(err.Error(), "discard the saved state") {
+			t.Fatalf("expected ErrMachineLocked for a saved machine, got %v", err)
+		}
+		if err := SetDescription(VM, "saved"); err != nil {
+			t.Fatal(err)
+		}
+		if err := (&Machine{Name: VM}).SetNIC(1, NIC{Network: NICNetNAT, Hardware: IntelPro1000MTDesktop}); !errors.Is(err, ErrMachineRunning) {
+			t.Fatalf("expected ErrMachineRunning, got %v", err)
+		}
+	}
+
+	Teardown()
+}
@@ -0,0 +1,121 @@
+package virtualbox
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxMachineNameLength is the longest machine name, in bytes, which still
+// leaves room for the settings file suffixes within the host file name limit.
+const MaxMachineNameLength = 240
+
+// machineNameReserved holds the characters VirtualBox cannot keep in the names
+// of the machine folder and settings file, on all the hosts.
+const machineNameReserved = `/\<>:"|?*`
+
+// ValidateMachineName checks that name can be used as a machine name as is.
+// The name may be qualified with the group of the machine, e.g.
+// "/prod/web/db1", where the group part must pass ValidateGroupPath; the
+// functions taking a new machine name then put the machine in that group.
+// Other slashes are rejected. It returns an error wrapping ErrInvalidArgument.
+func ValidateMachineName(name string) error {
+	group, base := splitMachineName(name)
+	if group != "" {
+		if err := ValidateGroupPath(group); err != nil || strings.HasPrefix(name, "//") {
+			return fmt.Errorf("%w: machine name '%s' has an invalid group", ErrInvalidArgument, name)
+		}
+	}
+	switch {
+	case base == "":
+		return fmt.Errorf("%w: machine name is empty", ErrInvalidArgument)
+	case len(base) > MaxMachineNameLength:
+		return fmt.Errorf("%w: machine name is longer than %d bytes", ErrInvalidArgument, MaxMachineNameLength)
+	case !utf8.ValidString(base):
+		return fmt.Errorf("%w: machine name '%s' is not valid UTF-8", ErrInvalidArgument, name)
+	case strings.TrimSpace(base) != base:
+		return fmt.Errorf("%w: machine name '%s' has leading or trailing whitespace", ErrInvalidArgument, name)
+	case strings.HasSuffix(base, "."):
+		return fmt.Errorf("%w: machine name '%s' ends with a dot", ErrInvalidArgument, name)
+	}
+	for _, r := range base {
+		if strings.ContainsRune(`/\`, r) {
+			return fmt.Errorf("%w: machine name '%s' contains '%c', groups must be absolute, e.g. '/group/name'", ErrInvalidArgument, name, r)
+		}
+		if unicode.IsControl(r) || strings.ContainsRune(machineNameReserved, r) {
+			return fmt.Errorf("%w: machine name '%s' contains %q", ErrInvalidArgument, name, r)
+		}
+	}
+	return nil
+}
+
+// splitMachineName splits a group qualified machine name, e.g. "/prod/web"
+// into "/prod" and "web". The group is empty for other names, including
+// relative ones such as "prod/web".
+func splitMachineName(name string) (group, base string) {
+	i := strings.LastIndex(name, "/")
+	if i < 0 || !strings.HasPrefix(name, "/") {
+		return "", name
+	}
+	if i == 0 {
+		return "/", name[1:]
+	}
+	return name[:i], name[i+1:]
+}
+
+// machineNameArgs returns the VBoxManage options naming a machine, and setting
+// its group when the name is group qualified. nameOpt is the option the
+// command takes the name with, e.g. "--name".
+func machineNameArgs(nameOpt, groupOpt, name string) []string {
+	group, base := splitMachineName(name)
+	args := []string{nameOpt, base}
+	if group != "" {
+		args = append(args, groupOpt, group)
+	}
+	return args
+}
+
+// SanitizeMachineName returns a variant of name which passes
+// ValidateMachineName: invalid characters are replaced with '_', surrounding
+// whitespace and trailing dots are trimmed, and long names are truncated. An
+// empty result gets replaced with "unnamed". A valid group qualifying the name
+// is kept.
+func SanitizeMachineName(name string) string {
+	if group, base := splitMachineName(name); group != "" && ValidateGroupPath(group) == nil {
+		return strings.TrimSuffix(group, "/") + "/" + SanitizeMachineName(base)
+	}
+	name = strings.ToValidUTF8(name, "_")
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || strings.ContainsRune(machineNameReserved, r) {
+			return '_'
+		}
+		return r
+	}, name)
+	if len(name) > MaxMachineNameLength {
+		end := MaxMachineNameLength
+		for !utf8.RuneStart(name[end]) {
+			end--
+		}
+		name = name[:end]
+	}
+	name = strings.TrimRight(strings.TrimSpace(name), ".")
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "unnamed"
+	}
+	return name
+}
+
+// RenameMachine renames the machine, which must not be running. The machine
+// folder and settings file are renamed along. A group qualified name also
+// moves the machine to that group, see RelocateMachine.
+func RenameMachine(vm, name string) error {
+	if err := ValidateMachineName(name); err != nil {
+		return err
+	}
+	if err := assertMutableOrSaved(vm); err != nil {
+		return err
+	}
+	return Manage().run(append([]string{"modifyvm", vm}, machineNameArgs("--name", "--groups", name)...)...)
+}
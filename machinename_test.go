@@ -0,0 +1,82 @@
+package virtualbox
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestValidateMachineName(t *testing.T) {
+	for _, name := range []string{"go-virtualbox", "Ubuntu 20.04 (web)", "été", "/prod/web", "/web"} {
+		if err := ValidateMachineName(name); err != nil {
+			t.Errorf("expected '%s' to be valid, got %v", name, err)
+		}
+	}
+	for _, name := range []string{"", " vm", "vm\t", "prod/web", `C:\vm`, "vm\x00", "vm.", "a|b", "/prod/", "//web", "/prod//web", "/prod,dev/web", "/prod/ web", strings.Repeat("x", MaxMachineNameLength+1)} {
+		if err := ValidateMachineName(name); !errors.Is(err, ErrInvalidArgument) {
+			t.Errorf("expected ErrInvalidArgument for %q, got %v", name, err)
+		}
+	}
+}
+
+func TestSanitizeMachineName(t *testing.T) {
+	for in, expected := range map[string]string{
+		"go-virtualbox":  "go-virtualbox",
+		" prod/web.. ":   "prod_web",
+		"a:b\nc":         "a_b_c",
+		"   ":            "unnamed",
+		"\xffvm":         "_vm",
+		"../../etc/vbox": ".._.._etc_vbox",
+		"/prod/we:b":     "/prod/we_b",
+		"/prod,x/web":    "_prod,x_web",
+	} {
+		got := SanitizeMachineName(in)
+		if got != expected {
+			t.Errorf("expected %q for %q, got %q", expected, in, got)
+		}
+		if err := ValidateMachineName(got); err != nil {
+			t.Errorf("sanitized %q is invalid: %v", in, err)
+		}
+	}
+	long := SanitizeMachineName(strings.Repeat("é", MaxMachineNameLength))
+	if err := ValidateMachineName(long); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRenameMachine(t *testing.T) {
+	Setup(t)
+
+	if err := RenameMachine(VM, "prod/web"); !errors.Is(err, ErrInvalidArgument) {
+		t.Fatalf("expected ErrInvalidArgument, got %v", err)
+	}
+	if err := CloneMachine(VM, " clone", false); !errors.Is(err, ErrInvalidArgument) {
+		t.Fatalf("expected ErrInvalidArgument, got %v", err)
+	}
+	if _, err := CreateMachine("vm\n", ""); !errors.Is(err, ErrInvalidArgument) {
+		t.Fatalf("expected ErrInvalidArgument, got %v", err)
+	}
+	if ManageMock != nil {
+		vmInfoOut := ReadTestData("vboxmanage-showvminfo-1.out")
+		gomock.InOrder(
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+			ManageMock.EXPECT().run("modifyvm", VM, "--name", "go-virtualbox-renamed").Return(nil).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+			ManageMock.EXPECT().run("modifyvm", VM, "--name", "web", "--groups", "/prod").Return(nil).Times(1),
+			ManageMock.EXPECT().run("clonevm", VM, "--name", "web2", "--groups", "/prod/eu", "--register").Return(nil).Times(1),
+		)
+		if err := RenameMachine(VM, "go-virtualbox-renamed"); err != nil {
+			t.Fatal(err)
+		}
+		if err := RenameMachine(VM, "/prod/web"); err != nil {
+			t.Fatal(err)
+		}
+		if err := CloneMachine(VM, "/prod/eu/web2", true); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	Teardown()
+}
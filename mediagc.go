@@ -0,0 +1,56 @@
+package virtualbox
+
+// PruneOrphanedMedia closes and deletes the registered disks no machine uses,
+// e.g. left over by failed imports, and returns their locations. With dryRun,
+// it only returns them. A disk is kept if it is attached to a machine, is the
+// parent of another disk, or is used by a snapshot.
+func PruneOrphanedMedia(dryRun bool) ([]string, error) {
+	disks, err := ListDisks()
+	if err != nil {
+		return nil, err
+	}
+	ms, err := ListMachines()
+	if err != nil {
+		return nil, err
+	}
+
+	used := map[string]bool{}
+	for _, d := range disks {
+		if d.ParentUUID != "" {
+			used[d.ParentUUID] = true
+		}
+	}
+	for _, m := range ms {
+		attachments, err := ListAttachments(m.UUID)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range attachments {
+			if a.UUID != "" {
+				used[a.UUID] = true
+			}
+		}
+	}
+
+	pruned := []string{}
+	for _, d := range disks {
+		if used[d.UUID] {
+			continue
+		}
+		// Snapshots may still refer to disks the current state no longer uses.
+		vms, err := mediumMachines(d.UUID)
+		if err != nil {
+			return pruned, err
+		}
+		if len(vms) > 0 {
+			continue
+		}
+		if !dryRun {
+			if err := Manage().run("closemedium", "disk", d.UUID, "--delete"); err != nil {
+				return pruned, err
+			}
+		}
+		pruned = append(pruned, d.Location)
+	}
+	return pruned, nil
+}
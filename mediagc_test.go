@@ -0,0 +1,45 @@
+package virtualbox
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestPruneOrphanedMedia(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		listHddsOut := ReadTestData("vboxmanage-list-hdds-1.out")
+		listVmsOut := `"go-virtualbox" {37f5d336-bf07-48dd-947c-37e6a56420a7}`
+		vmInfoOut := ReadTestData("vboxmanage-showvminfo-1.out")
+		mediumInfoOut := ReadTestData("vboxmanage-showmediuminfo-1.out")
+		for _, dryRun := range []bool{true, false} {
+			calls := []*gomock.Call{
+				ManageMock.EXPECT().runOut("list", "hdds").Return(listHddsOut, nil).Times(1),
+				ManageMock.EXPECT().runOut("list", "vms").Return(listVmsOut, nil).Times(1),
+				ManageMock.EXPECT().runOutErr("showvminfo", "go-virtualbox", "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+				ManageMock.EXPECT().runOutErr("showvminfo", "37f5d336-bf07-48dd-947c-37e6a56420a7", "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+				ManageMock.EXPECT().runOut("showmediuminfo", "disk", "6a1f0c9e-3b0e-4bd4-9d57-4e3b1bb4a0f2").Return("", nil).Times(1),
+			}
+			if !dryRun {
+				calls = append(calls, ManageMock.EXPECT().run("closemedium", "disk", "6a1f0c9e-3b0e-4bd4-9d57-4e3b1bb4a0f2", "--delete").Return(nil).Times(1))
+			}
+			// The differencing disk is only used by a snapshot of 'golden'.
+			calls = append(calls, ManageMock.EXPECT().runOut("showmediuminfo", "disk", "9b7e26f4-7d43-4c2e-a5e6-1c0f4c7b22c9").Return(mediumInfoOut, nil).Times(1))
+			gomock.InOrder(calls...)
+
+			pruned, err := PruneOrphanedMedia(dryRun)
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected := []string{"/tmp/failed-import/disk001.vdi"}
+			if !reflect.DeepEqual(pruned, expected) {
+				t.Fatalf("expected %q, got %q", expected, pruned)
+			}
+		}
+	}
+
+	Teardown()
+}
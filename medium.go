@@ -0,0 +1,211 @@
+package virtualbox
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// MediumType represents the attachment mode of a disk medium.
+type MediumType string
+
+const (
+	// MediumNormal is the default mode, with differencing images for snapshots.
+	MediumNormal = MediumType("normal")
+	// MediumImmutable discards all writes when the machine is powered off.
+	MediumImmutable = MediumType("immutable")
+	// MediumWritethrough is not affected by snapshots.
+	MediumWritethrough = MediumType("writethrough")
+	// MediumShareable can be attached to several running machines at once.
+	MediumShareable = MediumType("shareable")
+	// MediumReadonly is for read-only media such as DVD images.
+	MediumReadonly = MediumType("readonly")
+	// MediumMultiattach shares one base image between several machines.
+	MediumMultiattach = MediumType("multiattach")
+)
+
+var (
+	reMediumAttached = regexp.MustCompile(`(?:because|since) it is attached to`)
+	reMediumInUseBy  = regexp.MustCompile(`\(UUID: ([0-9a-f-]+)\)`)
+)
+
+var (
+	// ErrMediumAttached is returned when a medium cannot be changed because it is attached to a machine.
+	ErrMediumAttached = errors.New("medium is attached to a machine")
+)
+
+// SetMediumType changes the attachment mode of the disk medium at the given path.
+func SetMediumType(path string, typ MediumType) error {
+	switch typ {
+	case MediumNormal, MediumImmutable, MediumWritethrough, MediumShareable, MediumReadonly, MediumMultiattach:
+	default:
+		return fmt.Errorf("invalid medium type: '%s'", typ)
+	}
+	_, stderr, err := Manage().runOutErr("modifymedium", "disk", path, "--type", string(typ))
+	if err != nil {
+		if reMediumAttached.MatchString(stderr) {
+			return ErrMediumAttached
+		}
+		return err
+	}
+	return nil
+}
+
+// SetMediumProperty sets a property of the disk medium at the given path.
+func SetMediumProperty(path, key, value string) error {
+	return Manage().run("mediumproperty", "disk", "set", path, key, value)
+}
+
+// GetMediumProperty reads a property of the disk medium at the given path.
+func GetMediumProperty(path, key string) (string, error) {
+	out, err := Manage().runOut("mediumproperty", "disk", "get", path, key)
+	if err != nil {
+		return "", err
+	}
+	props := parseMediumProperties(out)
+	val, ok := props[key]
+	if !ok {
+		return "", fmt.Errorf("no medium property '%s' set on '%s'", key, path)
+	}
+	return val, nil
+}
+
+// ListMediumProperties reads all the properties of the disk medium at the
+// given path, keyed by name.
+func ListMediumProperties(path string) (map[string]string, error) {
+	// mediumproperty cannot enumerate properties, but showmediuminfo prints
+	// them as "Property:" lines.
+	out, err := Manage().runOut("showmediuminfo", "disk", path)
+	if err != nil {
+		return nil, err
+	}
+	props := map[string]string{}
+	s := bufio.NewScanner(strings.NewReader(out))
+	for s.Scan() {
+		res := reColonLine.FindStringSubmatch(s.Text())
+		if res == nil || res[1] != "Property" {
+			continue
+		}
+		if kv := strings.SplitN(res[2], "=", 2); len(kv) == 2 {
+			props[kv[0]] = kv[1]
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return props, nil
+}
+
+// parseMediumProperties parses the "key=value" lines printed by mediumproperty get.
+func parseMediumProperties(out string) map[string]string {
+	props := map[string]string{}
+	s := bufio.NewScanner(strings.NewReader(out))
+	for s.Scan() {
+		if kv := strings.SplitN(strings.TrimSpace(s.Text()), "=", 2); len(kv) == 2 {
+			props[kv[0]] = kv[1]
+		}
+	}
+	return props
+}
+
+// mediumMachines lists the UUIDs of the machines using the disk medium at the
+// given path, from the "In use by VMs" part of showmediuminfo.
+func mediumMachines(path string) ([]string, error) {
+	out, err := Manage().runOut("showmediuminfo", "disk", path)
+	if err != nil {
+		return nil, err
+	}
+	var uuids []string
+	for _, res := range reMediumInUseBy.FindAllStringSubmatch(out, -1) {
+		uuids = append(uuids, res[1])
+	}
+	return uuids, nil
+}
+
+// MoveMedium moves the disk medium file at oldPath to newPath, keeping the
+// VirtualBox media registry up to date, which moving the file by hand would
+// not. The destination directory must exist, and the medium must not be in use
+// by a running machine.
+func MoveMedium(oldPath, newPath string) error {
+	if err := checkWritableDir(filepath.Dir(newPath)); err != nil {
+		return err
+	}
+	uuids, err := mediumMachines(oldPath)
+	if err != nil {
+		return err
+	}
+	for _, uuid := range uuids {
+		r, err := isRunning(uuid)
+		if err != nil {
+			return err
+		}
+		if r {
+			return fmt.Errorf("cannot move '%s' used by running machine %s: %w", oldPath, uuid, ErrMediumAttached)
+		}
+	}
+	_, stderr, err := Manage().runOutErr("modifymedium", "disk", oldPath, "--move", newPath)
+	if err != nil {
+		if reMediumAttached.MatchString(stderr) {
+			return ErrMediumAttached
+		}
+		return err
+	}
+	return nil
+}
+
+// Disk describes a registered hard disk medium.
+type Disk struct {
+	UUID       string
+	ParentUUID string // empty for a base image
+	State      string // e.g. created or inaccessible
+	Type       string // e.g. "normal (base)"
+	Location   string
+	Format     string // e.g. VDI or VMDK
+}
+
+// ListDisks lists the registered hard disk media.
+func ListDisks() ([]Disk, error) {
+	out, err := Manage().runOut("list", "hdds")
+	if err != nil {
+		return nil, err
+	}
+	var disks []Disk
+	var d *Disk
+	s := bufio.NewScanner(strings.NewReader(out))
+	for s.Scan() {
+		res := reColonLine.FindStringSubmatch(s.Text())
+		if res == nil {
+			continue
+		}
+		key, val := strings.TrimSpace(res[1]), strings.TrimSpace(res[2])
+		if key == "UUID" {
+			disks = append(disks, Disk{UUID: val})
+			d = &disks[len(disks)-1]
+			continue
+		}
+		if d == nil {
+			continue
+		}
+		switch key {
+		case "Parent UUID":
+			if val != "base" {
+				d.ParentUUID = val
+			}
+		case "State":
+			d.State = val
+		case "Type":
+			d.Type = val
+		case "Location":
+			d.Location = val
+		case "Storage format":
+			d.Format = val
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return disks, nil
+}
@@ -0,0 +1,121 @@
+package virtualbox
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestSetMediumType(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		ManageMock.EXPECT().runOutErr("modifymedium", "disk", "base.vdi", "--type", "multiattach").Return("", "", nil).Times(1)
+	}
+	if err := SetMediumType("base.vdi", MediumMultiattach); err != nil {
+		t.Fatal(err)
+	}
+
+	if ManageMock != nil {
+		stderr := "VBoxManage: error: Cannot change the type of medium 'base.vdi' because it is attached to 1 virtual machines\n"
+		ManageMock.EXPECT().runOutErr("modifymedium", "disk", "base.vdi", "--type", "immutable").Return("", stderr, errors.New("exit status 1")).Times(1)
+		if err := SetMediumType("base.vdi", MediumImmutable); err != ErrMediumAttached {
+			t.Fatalf("expected ErrMediumAttached, got %v", err)
+		}
+	}
+
+	if err := SetMediumType("base.vdi", MediumType("bogus")); err == nil {
+		t.Fatal("expected an error for an invalid medium type")
+	}
+
+	Teardown()
+}
+
+func TestMediumProperty(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		gomock.InOrder(
+			ManageMock.EXPECT().run("mediumproperty", "disk", "set", "base.vdi", "Description", "golden image").Return(nil).Times(1),
+			ManageMock.EXPECT().runOut("mediumproperty", "disk", "get", "base.vdi", "Description").Return("Description=golden image\n", nil).Times(1),
+			ManageMock.EXPECT().runOut("mediumproperty", "disk", "get", "base.vdi", "Owner").Return("", nil).Times(1),
+		)
+		if err := SetMediumProperty("base.vdi", "Description", "golden image"); err != nil {
+			t.Fatal(err)
+		}
+		v, err := GetMediumProperty("base.vdi", "Description")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v != "golden image" {
+			t.Fatalf("expected 'golden image', got '%s'", v)
+		}
+		if _, err := GetMediumProperty("base.vdi", "Owner"); err == nil || !strings.Contains(err.Error(), "no medium property 'Owner'") {
+			t.Fatalf("expected a missing property error, got %v", err)
+		}
+	}
+
+	Teardown()
+}
+
+func TestListMediumProperties(t *testing.T) {
+	Setup(t)
+
+	disk := "/Users/fix/VirtualBox VMs/go-virtualbox/ubuntu-16.04-amd64-disk001.vmdk"
+	if ManageMock != nil {
+		out := ReadTestData("vboxmanage-showmediuminfo-1.out")
+		ManageMock.EXPECT().runOut("showmediuminfo", "disk", disk).Return(out, nil).Times(1)
+	}
+	props, err := ListMediumProperties(disk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("%+v", props)
+	if ManageMock != nil && props["Special/GUI/SaveMountedISOs"] != "yes" {
+		t.Fatalf("unexpected properties: %+v", props)
+	}
+
+	Teardown()
+}
+
+func TestMoveMedium(t *testing.T) {
+	Setup(t)
+
+	running.vms = nil
+	if ManageMock != nil {
+		dir, err := ioutil.TempDir("", "go-virtualbox-test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+		out := ReadTestData("vboxmanage-showmediuminfo-1.out")
+		dst := filepath.Join(dir, "disk001.vmdk")
+		gomock.InOrder(
+			ManageMock.EXPECT().runOut("showmediuminfo", "disk", "disk.vmdk").Return(out, nil).Times(1),
+			ManageMock.EXPECT().runOut("list", "runningvms").Return(ReadTestData("vboxmanage-list-vms-1.out"), nil).Times(1),
+			ManageMock.EXPECT().runOutErr("modifymedium", "disk", "disk.vmdk", "--move", dst).Return("", "", nil).Times(1),
+			ManageMock.EXPECT().runOut("showmediuminfo", "disk", "disk.vmdk").Return(out, nil).Times(1),
+		)
+		if err := MoveMedium("disk.vmdk", dst); err != nil {
+			t.Fatal(err)
+		}
+
+		running.vms = map[string]string{"37f5d336-bf07-48dd-947c-37e6a56420a7": "go-virtualbox"}
+		running.at = time.Now()
+		if err := MoveMedium("disk.vmdk", dst); !errors.Is(err, ErrMediumAttached) {
+			t.Fatalf("expected ErrMediumAttached, got %v", err)
+		}
+		running.vms = nil
+	}
+	if err := MoveMedium("disk.vmdk", filepath.Join("does", "not", "exist.vmdk")); err == nil {
+		t.Fatal("expected an error for a missing destination directory")
+	}
+
+	Teardown()
+}
@@ -33,7 +33,7 @@ func (m *MockCommand) EXPECT() *MockCommandMockRecorder {
 }
 
 // setOpts mocks base method
-func (m *MockCommand) setOpts(opts ...option) Command {
+func (m *MockCommand) setOpts(opts ...Option) Command {
 	varargs := []interface{}{}
 	for _, a := range opts {
 		varargs = append(varargs, a)
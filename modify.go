@@ -0,0 +1,140 @@
+package virtualbox
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ModifyBuilder accumulates machine settings to apply them in a single
+// modifyvm call, which costs a single subprocess and makes VirtualBox apply
+// them all or none. Build one with BeginModify, e.g.
+//
+//	err := BeginModify(vm).CPUs(2).Memory(4096).NIC(1, nic).Apply()
+//
+// The first invalid setting is reported by Apply.
+type ModifyBuilder struct {
+	vm   string
+	opts []string // option names, in the order they were first set
+	vals map[string]string
+	nic  int // highest NIC index set, 0 if none
+	err  error
+}
+
+// BeginModify starts building the settings of the machine.
+func BeginModify(vm string) *ModifyBuilder {
+	return &ModifyBuilder{vm: vm, vals: map[string]string{}}
+}
+
+// Set sets any modifyvm option, given without the leading dashes, e.g.
+// Set("vrde", "on"). Setting an option again replaces its value.
+func (b *ModifyBuilder) Set(opt, val string) *ModifyBuilder {
+	opt = "--" + strings.TrimLeft(opt, "-")
+	if _, ok := b.vals[opt]; !ok {
+		b.opts = append(b.opts, opt)
+	}
+	b.vals[opt] = val
+	return b
+}
+
+// fail records the first invalid setting.
+func (b *ModifyBuilder) fail(err error) *ModifyBuilder {
+	if b.err == nil {
+		b.err = err
+	}
+	return b
+}
+
+// CPUs sets the number of virtual CPUs.
+func (b *ModifyBuilder) CPUs(n uint) *ModifyBuilder {
+	if n == 0 {
+		return b.fail(fmt.Errorf("invalid CPU count: 0"))
+	}
+	return b.Set("cpus", strconv.FormatUint(uint64(n), 10))
+}
+
+// Memory sets the main memory, in MB.
+func (b *ModifyBuilder) Memory(mb uint) *ModifyBuilder {
+	if mb == 0 {
+		return b.fail(fmt.Errorf("invalid memory size: 0"))
+	}
+	return b.Set("memory", strconv.FormatUint(uint64(mb), 10))
+}
+
+// VRAM sets the video memory, in MB.
+func (b *ModifyBuilder) VRAM(mb uint) *ModifyBuilder {
+	return b.Set("vram", strconv.FormatUint(uint64(mb), 10))
+}
+
+// OSType sets the guest OS type id, e.g. "Ubuntu_64".
+func (b *ModifyBuilder) OSType(id string) *ModifyBuilder {
+	return b.Set("ostype", id)
+}
+
+// Description sets the description of the machine.
+func (b *ModifyBuilder) Description(description string) *ModifyBuilder {
+	return b.Set("description", description)
+}
+
+// Flag toggles the given flags, e.g. Flag(ACPI|IOAPIC, true).
+func (b *ModifyBuilder) Flag(f Flag, on bool) *ModifyBuilder {
+	for i, name := range flagNames {
+		if f&(1<<uint(i)) != 0 {
+			b.Set(name, bool2string(on))
+		}
+	}
+	return b
+}
+
+// Chipset sets the emulated chipset.
+func (b *ModifyBuilder) Chipset(chipset ChipsetType) *ModifyBuilder {
+	switch chipset {
+	case ChipsetPIIX3, ChipsetICH9:
+	default:
+		return b.fail(fmt.Errorf("invalid chipset: '%s'", chipset))
+	}
+	return b.Set("chipset", string(chipset))
+}
+
+// NIC sets the n-th NIC (starting at 1), like Machine.SetNIC.
+func (b *ModifyBuilder) NIC(n int, nic NIC) *ModifyBuilder {
+	if err := checkNICIndex(n); err != nil {
+		return b.fail(err)
+	}
+	if n > b.nic {
+		b.nic = n
+	}
+	args := nicArgs(n, nic)
+	for i := 0; i+1 < len(args); i += 2 {
+		b.Set(args[i], args[i+1])
+	}
+	return b
+}
+
+// Args returns the modifyvm arguments built so far.
+func (b *ModifyBuilder) Args() []string {
+	args := []string{"modifyvm", b.vm}
+	for _, opt := range b.opts {
+		args = append(args, opt, b.vals[opt])
+	}
+	return args
+}
+
+// Apply applies the settings in a single modifyvm call. The machine must not
+// be running. Nothing is run when there are no settings.
+func (b *ModifyBuilder) Apply() error {
+	if b.err != nil {
+		return b.err
+	}
+	if len(b.opts) == 0 {
+		return nil
+	}
+	if b.nic > 0 {
+		if err := assertMutableNIC(b.vm, b.nic); err != nil {
+			return err
+		}
+	} else if err := assertMutable(b.vm); err != nil {
+		return err
+	}
+	return Manage().run(b.Args()...)
+}
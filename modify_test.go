@@ -0,0 +1,52 @@
+package virtualbox
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestModifyBuilder(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		vmInfoOut := ReadTestData("vboxmanage-showvminfo-poweroff.out")
+		gomock.InOrder(
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+			ManageMock.EXPECT().run("modifyvm", VM,
+				"--cpus", "4",
+				"--memory", "4096",
+				"--acpi", "on",
+				"--ioapic", "on",
+				"--nic1", "nat",
+				"--nictype1", "virtio",
+				"--cableconnected1", "on",
+				"--vrde", "off",
+			).Return(nil).Times(1),
+		)
+		err := BeginModify(VM).
+			CPUs(2).
+			Memory(4096).
+			Flag(ACPI|IOAPIC, true).
+			NIC(1, NIC{Network: NICNetNAT, Hardware: VirtIO}).
+			Set("vrde", "off").
+			CPUs(4).
+			Apply()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(1)
+		if err := BeginModify(VM).NIC(9, NIC{}).CPUs(2).Apply(); err == nil {
+			t.Fatal("expected an error for NIC 9 of a PIIX3 machine")
+		}
+	}
+	if err := BeginModify(VM).NIC(37, NIC{}).CPUs(2).Apply(); err == nil {
+		t.Fatal("expected an error for an invalid NIC index")
+	}
+	if err := BeginModify(VM).Apply(); err != nil {
+		t.Fatal(err)
+	}
+
+	Teardown()
+}
@@ -0,0 +1,56 @@
+package virtualbox
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// NATEngineConfig holds the tunables of the NAT engine behind a NAT-ed NIC.
+type NATEngineConfig struct {
+	DNSHostResolver bool       // use the host resolver API instead of a DNS proxy
+	DNSProxy        bool       // proxy guest DNS requests to the host DNS servers
+	Network         *net.IPNet // custom NAT subnet, nil keeps the default 10.0.x.0/24
+	TFTPServer      net.IP     // nil keeps the default TFTP server address
+	AliasLog        bool       // log the NAT aliasing
+	AliasProxyOnly  bool       // only alias, do not masquerade
+	AliasSamePorts  bool       // try to keep the guest source ports
+}
+
+// ConfigureNATEngine applies the NAT engine settings of the n-th NIC of the
+// machine in a single modifyvm call. The machine must not be running.
+func ConfigureNATEngine(vm string, nic int, cfg NATEngineConfig) error {
+	if err := checkNICIndex(nic); err != nil {
+		return err
+	}
+	if err := assertMutableNIC(vm, nic); err != nil {
+		return err
+	}
+	args := []string{"modifyvm", vm,
+		fmt.Sprintf("--natdnshostresolver%d", nic), bool2string(cfg.DNSHostResolver),
+		fmt.Sprintf("--natdnsproxy%d", nic), bool2string(cfg.DNSProxy),
+	}
+	if cfg.Network != nil {
+		args = append(args, fmt.Sprintf("--natnet%d", nic), cfg.Network.String())
+	}
+	if cfg.TFTPServer != nil {
+		args = append(args, fmt.Sprintf("--nattftpserver%d", nic), cfg.TFTPServer.String())
+	}
+
+	var modes []string
+	if cfg.AliasLog {
+		modes = append(modes, "log")
+	}
+	if cfg.AliasProxyOnly {
+		modes = append(modes, "proxyonly")
+	}
+	if cfg.AliasSamePorts {
+		modes = append(modes, "sameports")
+	}
+	if len(modes) == 0 {
+		modes = append(modes, "default")
+	}
+	args = append(args, fmt.Sprintf("--nataliasmode%d", nic), strings.Join(modes, ","))
+
+	return Manage().run(args...)
+}
@@ -0,0 +1,56 @@
+package virtualbox
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestConfigureNATEngine(t *testing.T) {
+	Setup(t)
+
+	if err := ConfigureNATEngine(VM, 37, NATEngineConfig{}); err == nil || !strings.Contains(err.Error(), "invalid NIC index 37") {
+		t.Fatalf("expected an invalid NIC index error, got %v", err)
+	}
+	if ManageMock != nil {
+		_, subnet, err := net.ParseCIDR("192.168.15.0/24")
+		if err != nil {
+			t.Fatal(err)
+		}
+		vmInfoOut := ReadTestData("vboxmanage-showvminfo-poweroff.out")
+		gomock.InOrder(
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+			ManageMock.EXPECT().run("modifyvm", VM,
+				"--natdnshostresolver2", "on",
+				"--natdnsproxy2", "off",
+				"--natnet2", "192.168.15.0/24",
+				"--nattftpserver2", "192.168.15.4",
+				"--nataliasmode2", "log,proxyonly,sameports",
+			).Return(nil).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+			ManageMock.EXPECT().run("modifyvm", VM,
+				"--natdnshostresolver1", "off",
+				"--natdnsproxy1", "off",
+				"--nataliasmode1", "default",
+			).Return(nil).Times(1),
+		)
+		cfg := NATEngineConfig{
+			DNSHostResolver: true,
+			Network:         subnet,
+			TFTPServer:      net.ParseIP("192.168.15.4"),
+			AliasLog:        true,
+			AliasProxyOnly:  true,
+			AliasSamePorts:  true,
+		}
+		if err := ConfigureNATEngine(VM, 2, cfg); err != nil {
+			t.Fatal(err)
+		}
+		if err := ConfigureNATEngine(VM, 1, NATEngineConfig{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	Teardown()
+}
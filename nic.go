@@ -1,11 +1,17 @@
 package virtualbox
 
+import (
+	"fmt"
+	"strings"
+)
+
 // NIC represents a virtualized network interface card.
 type NIC struct {
-	Network       NICNetwork
-	Hardware      NICHardware
-	HostInterface string // The host interface name to bind to in 'hostonly' and 'bridged' mode
-	MacAddr       string
+	Network       NICNetwork        `json:"network"`
+	Hardware      NICHardware       `json:"hardware"`
+	HostInterface string            `json:"host_interface"` // The host interface name to bind to in 'hostonly' and 'bridged' mode
+	MacAddr       string            `json:"mac_addr"`
+	PFRules       map[string]PFRule `json:"pf_rules,omitempty"` // port forwarding rules by name, in 'nat' mode
 }
 
 // NICNetwork represents the type of NIC networks.
@@ -26,6 +32,8 @@ const (
 	NICNetHostonly = NICNetwork("hostonly")
 	// NICNetGeneric when the NIC behaves like a standard physical one.
 	NICNetGeneric = NICNetwork("generic")
+	// NICNetNATNetwork when the NIC is attached to a shared NAT network.
+	NICNetNATNetwork = NICNetwork("natnetwork")
 )
 
 // NICHardware represents the type of NIC hardware.
@@ -45,3 +53,148 @@ const (
 	// VirtIO when the NIC emulates a virtio.
 	VirtIO = NICHardware("virtio")
 )
+
+// NICConfig describes the current configuration of a single NIC.
+type NICConfig struct {
+	Index          int         `json:"index"`
+	Network        NICNetwork  `json:"network"`
+	Hardware       NICHardware `json:"hardware"`
+	MacAddr        string      `json:"mac_addr"`
+	CableConnected bool        `json:"cable_connected"`
+	Attachment     string      `json:"attachment"` // host interface, internal network, NAT network or generic driver name
+}
+
+// GetNIC reads the configuration of the n-th NIC (starting at 1) of the machine.
+func GetNIC(vm string, n int) (*NICConfig, error) {
+	propMap, err := machineProps(vm)
+	if err != nil {
+		return nil, err
+	}
+	return parseNIC(propMap, n)
+}
+
+func parseNIC(propMap map[string]string, n int) (*NICConfig, error) {
+	network, ok := propMap[fmt.Sprintf("nic%d", n)]
+	if !ok || network == string(NICNetAbsent) {
+		return nil, fmt.Errorf("NIC %d is not configured", n)
+	}
+	nic := &NICConfig{
+		Index:          n,
+		Network:        NICNetwork(network),
+		Hardware:       NICHardware(propMap[fmt.Sprintf("nictype%d", n)]),
+		MacAddr:        propMap[fmt.Sprintf("macaddress%d", n)],
+		CableConnected: propMap[fmt.Sprintf("cableconnected%d", n)] == "on",
+	}
+	switch nic.Network {
+	case NICNetHostonly:
+		nic.Attachment = propMap[fmt.Sprintf("hostonlyadapter%d", n)]
+	case NICNetBridged:
+		nic.Attachment = propMap[fmt.Sprintf("bridgeadapter%d", n)]
+	case NICNetInternal:
+		nic.Attachment = propMap[fmt.Sprintf("intnet%d", n)]
+	case NICNetNATNetwork:
+		nic.Attachment = propMap[fmt.Sprintf("nat-network%d", n)]
+	case NICNetGeneric:
+		nic.Attachment = propMap[fmt.Sprintf("generic%d", n)]
+	}
+	return nic, nil
+}
+
+// checkNICIndex rejects NIC indexes out of the 1--36 range of modifyvm. How
+// many NICs a machine has depends on its chipset, see assertMutableNIC.
+func checkNICIndex(n int) error {
+	if limit := maxNICs(ChipsetICH9); n < 1 || n > limit {
+		return fmt.Errorf("invalid NIC index %d, must be in 1--%d", n, limit)
+	}
+	return nil
+}
+
+// assertMutableNIC is assertMutable also checking that the chipset of the
+// machine has the n-th NIC.
+func assertMutableNIC(vm string, n int) error {
+	props, err := mutableProps(vm, false)
+	if err != nil {
+		return err
+	}
+	chipset := ChipsetType(props["chipset"])
+	if limit := maxNICs(chipset); n > limit {
+		return fmt.Errorf("invalid NIC index %d, '%s' has a %s chipset with NICs 1--%d", n, vm, chipset, limit)
+	}
+	return nil
+}
+
+// SetNICProperty sets a property of the backend driver of the n-th NIC of the
+// machine, e.g. for the generic driver. The machine must not be running.
+func SetNICProperty(vm string, n int, key, value string) error {
+	if err := checkNICIndex(n); err != nil {
+		return err
+	}
+	if key == "" || strings.Contains(key, "=") {
+		return fmt.Errorf("invalid NIC property name: '%s'", key)
+	}
+	if err := assertMutableNIC(vm, n); err != nil {
+		return err
+	}
+	return Manage().run("modifyvm", vm, fmt.Sprintf("--nicproperty%d", n), key+"="+value)
+}
+
+// SetNICBootPrio sets the PXE boot priority of the n-th NIC of the machine,
+// from 1 (highest) to 4 (lowest), or 0 for the default. The machine must not
+// be running.
+func SetNICBootPrio(vm string, n, prio int) error {
+	if err := checkNICIndex(n); err != nil {
+		return err
+	}
+	if prio < 0 || prio > 4 {
+		return fmt.Errorf("invalid NIC boot priority %d, must be in 0--4", prio)
+	}
+	if err := assertMutableNIC(vm, n); err != nil {
+		return err
+	}
+	return Manage().run("modifyvm", vm, fmt.Sprintf("--nicbootprio%d", n), fmt.Sprintf("%d", prio))
+}
+
+// SetNICSpeed sets the link speed (in kbps) the n-th NIC of the machine
+// reports to the guest. The machine must not be running.
+func SetNICSpeed(vm string, n int, kbps uint) error {
+	if err := checkNICIndex(n); err != nil {
+		return err
+	}
+	if err := assertMutableNIC(vm, n); err != nil {
+		return err
+	}
+	return Manage().run("modifyvm", vm, fmt.Sprintf("--nicspeed%d", n), fmt.Sprintf("%d", kbps))
+}
+
+// RandomizeMAC gives the n-th NIC of the machine a new random MAC address, e.g.
+// after cloning or importing it next to the original machine. The machine must
+// not be running.
+func RandomizeMAC(vm string, nic int) error {
+	if err := checkNICIndex(nic); err != nil {
+		return err
+	}
+	if err := assertMutableNIC(vm, nic); err != nil {
+		return err
+	}
+	return Manage().run("modifyvm", vm, fmt.Sprintf("--macaddress%d", nic), "auto")
+}
+
+// RandomizeAllMACs gives every configured NIC of the machine a new random MAC
+// address, in a single modifyvm call. The machine must not be running. ICH9
+// machines can have up to 36 NICs, PIIX3 ones 8.
+func RandomizeAllMACs(vm string) error {
+	propMap, err := mutableProps(vm, false)
+	if err != nil {
+		return err
+	}
+	args := []string{"modifyvm", vm}
+	for n := 1; n <= maxNICs(ChipsetType(propMap["chipset"])); n++ {
+		if network, ok := propMap[fmt.Sprintf("nic%d", n)]; ok && network != string(NICNetAbsent) {
+			args = append(args, fmt.Sprintf("--macaddress%d", n), "auto")
+		}
+	}
+	if len(args) == 2 {
+		return nil // no NIC
+	}
+	return Manage().run(args...)
+}
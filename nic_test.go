@@ -0,0 +1,116 @@
+package virtualbox
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestGetNIC(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		vmInfoOut := ReadTestData("vboxmanage-showvminfo-1.out")
+		gomock.InOrder(
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(2),
+		)
+	}
+	nic, err := GetNIC(VM, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("%+v", nic)
+	if ManageMock != nil {
+		if nic.Network != NICNetNAT || nic.Hardware != IntelPro1000MTDesktop || nic.MacAddr != "080027EE1DF7" || !nic.CableConnected {
+			t.Fatalf("unexpected NIC: %+v", nic)
+		}
+		if _, err := GetNIC(VM, 2); err == nil {
+			t.Fatal("expected an error for an unconfigured NIC")
+		}
+	}
+
+	Teardown()
+}
+
+func TestSetNICProperty(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		vmInfoOut := ReadTestData("vboxmanage-showvminfo-poweroff.out")
+		gomock.InOrder(
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+			ManageMock.EXPECT().run("modifyvm", VM, "--nicproperty2", "dest=10.0.0.1 port=4789").Return(nil).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+			ManageMock.EXPECT().run("modifyvm", VM, "--nicbootprio1", "1").Return(nil).Times(1),
+		)
+		if err := SetNICProperty(VM, 2, "dest", "10.0.0.1 port=4789"); err != nil {
+			t.Fatal(err)
+		}
+		if err := SetNICBootPrio(VM, 1, 1); err != nil {
+			t.Fatal(err)
+		}
+
+		ich9 := strings.Replace(vmInfoOut, `chipset="piix3"`, `chipset="ich9"`, 1)
+		gomock.InOrder(
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(ich9, "", nil).Times(1),
+			ManageMock.EXPECT().run("modifyvm", VM, "--nicspeed12", "1000000").Return(nil).Times(1),
+		)
+		if err := SetNICSpeed(VM, 12, 1000000); err == nil {
+			t.Fatal("expected an error for NIC 12 of a PIIX3 machine")
+		}
+		if err := SetNICSpeed(VM, 12, 1000000); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := SetNICProperty(VM, 37, "dest", "10.0.0.1"); err == nil {
+		t.Fatal("expected an error for an invalid NIC index")
+	}
+	if err := SetNICBootPrio(VM, 1, 5); err == nil {
+		t.Fatal("expected an error for an invalid boot priority")
+	}
+
+	Teardown()
+}
+
+func TestRandomizeAllMACs(t *testing.T) {
+	Setup(t)
+
+	if err := RandomizeMAC(VM, 0); err == nil {
+		t.Fatal("expected an error for an invalid NIC index")
+	}
+	if ManageMock != nil {
+		vmInfoOut := ReadTestData("vboxmanage-showvminfo-poweroff.out")
+		twoNICs := strings.Replace(vmInfoOut, `nic3="none"`, `nic3="intnet"`, 1)
+		ich9 := strings.Replace(vmInfoOut, `chipset="piix3"`, `chipset="ich9"`, 1) + "nic36=\"bridged\"\n"
+		gomock.InOrder(
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(twoNICs, "", nil).Times(1),
+			ManageMock.EXPECT().run("modifyvm", VM, "--macaddress1", "auto", "--macaddress3", "auto").Return(nil).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+			ManageMock.EXPECT().run("modifyvm", VM, "--macaddress2", "auto").Return(nil).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(ich9, "", nil).Times(1),
+			ManageMock.EXPECT().run("modifyvm", VM, "--macaddress1", "auto", "--macaddress36", "auto").Return(nil).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(ich9, "", nil).Times(1),
+			ManageMock.EXPECT().run("modifyvm", VM, "--macaddress20", "auto").Return(nil).Times(1),
+		)
+		if err := RandomizeAllMACs(VM); err != nil {
+			t.Fatal(err)
+		}
+		if err := RandomizeMAC(VM, 2); err != nil {
+			t.Fatal(err)
+		}
+		if err := RandomizeMAC(VM, 9); err == nil {
+			t.Fatal("expected an error for NIC 9 of a PIIX3 machine")
+		}
+		if err := RandomizeAllMACs(VM); err != nil {
+			t.Fatal(err)
+		}
+		if err := RandomizeMAC(VM, 20); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	Teardown()
+}
@@ -0,0 +1,81 @@
+package virtualbox
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+)
+
+// GuestOSType is a guest operating system type known to VirtualBox.
+type GuestOSType struct {
+	ID                string // e.g. "Ubuntu_64", as passed to --ostype
+	Description       string // e.g. "Ubuntu (64-bit)", as reported by showvminfo
+	FamilyID          string
+	FamilyDescription string
+	Is64Bit           bool
+}
+
+// ListOSTypes lists the guest operating system types known to VirtualBox.
+func ListOSTypes() ([]GuestOSType, error) {
+	out, err := Manage().runOut("list", "ostypes")
+	if err != nil {
+		return nil, err
+	}
+	var types []GuestOSType
+	var t *GuestOSType
+	s := bufio.NewScanner(strings.NewReader(out))
+	for s.Scan() {
+		res := reColonLine.FindStringSubmatch(s.Text())
+		if res == nil {
+			continue
+		}
+		key, val := strings.TrimSpace(res[1]), strings.TrimSpace(res[2])
+		if key == "ID" {
+			types = append(types, GuestOSType{ID: val})
+			t = &types[len(types)-1]
+			continue
+		}
+		if t == nil {
+			continue
+		}
+		switch key {
+		case "Description":
+			t.Description = val
+		case "Family ID":
+			t.FamilyID = val
+		case "Family Desc":
+			t.FamilyDescription = val
+		case "64 bit":
+			t.Is64Bit = val == "true"
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return types, nil
+}
+
+// SetOSType sets the guest operating system type of the machine, e.g. to fix
+// the one guessed when importing an appliance. The id must be one of
+// ListOSTypes, e.g. "Ubuntu_64", and the machine must not be running. The new
+// type shows in the OSTypeDescription of the machine.
+func SetOSType(vm, id string) error {
+	types, err := ListOSTypes()
+	if err != nil {
+		return err
+	}
+	known := false
+	for _, t := range types {
+		if t.ID == id {
+			known = true
+			break
+		}
+	}
+	if !known {
+		return fmt.Errorf("%w: unknown os type '%s'", ErrInvalidArgument, id)
+	}
+	if err := assertMutable(vm); err != nil {
+		return err
+	}
+	return Manage().run("modifyvm", vm, "--ostype", id)
+}
@@ -0,0 +1,49 @@
+package virtualbox
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestSetOSType(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		listOut := ReadTestData("vboxmanage-list-ostypes-1.out")
+		vmInfoOut := ReadTestData("vboxmanage-showvminfo-poweroff.out")
+		gomock.InOrder(
+			ManageMock.EXPECT().runOut("list", "ostypes").Return(listOut, nil).Times(1),
+			ManageMock.EXPECT().runOut("list", "ostypes").Return(listOut, nil).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+			ManageMock.EXPECT().run("modifyvm", VM, "--ostype", "Ubuntu_64").Return(nil).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+			ManageMock.EXPECT().runOut("list", "ostypes").Return(listOut, nil).Times(1),
+		)
+		types, err := ListOSTypes()
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := GuestOSType{ID: "Ubuntu_64", Description: "Ubuntu (64-bit)", FamilyID: "Linux", FamilyDescription: "Linux", Is64Bit: true}
+		if len(types) != 3 || !reflect.DeepEqual(types[2], expected) {
+			t.Fatalf("expected %+v last of 3, got %+v", expected, types)
+		}
+		if err := SetOSType(VM, "Ubuntu_64"); err != nil {
+			t.Fatal(err)
+		}
+		m, err := GetMachine(VM)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m.OSTypeDescription != "Ubuntu (64-bit)" {
+			t.Fatalf("unexpected os type description: '%s'", m.OSTypeDescription)
+		}
+		if err := SetOSType(VM, "Plan9"); !errors.Is(err, ErrInvalidArgument) {
+			t.Fatalf("expected ErrInvalidArgument, got %v", err)
+		}
+	}
+
+	Teardown()
+}
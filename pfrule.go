@@ -3,6 +3,9 @@ package virtualbox
 import (
 	"fmt"
 	"net"
+	"sort"
+	"strconv"
+	"strings"
 )
 
 // PFRule represents a port forwarding rule.
@@ -49,3 +52,185 @@ func grab(r PFRule) (string, string) {
 	}
 	return hostip, guestip
 }
+
+// parsePFRule parses a "name,proto,hostip,hostport,guestip,guestport" rule,
+// as printed by showvminfo.
+func parsePFRule(val string) (string, PFRule, error) {
+	f := strings.Split(val, ",")
+	if len(f) != 6 {
+		return "", PFRule{}, fmt.Errorf("invalid port forwarding rule: '%s'", val)
+	}
+	hostPort, err := strconv.ParseUint(f[3], 10, 16)
+	if err != nil {
+		return "", PFRule{}, fmt.Errorf("invalid port forwarding rule: '%s': %w", val, err)
+	}
+	guestPort, err := strconv.ParseUint(f[5], 10, 16)
+	if err != nil {
+		return "", PFRule{}, fmt.Errorf("invalid port forwarding rule: '%s': %w", val, err)
+	}
+	return f[0], PFRule{
+		Proto:     PFProto(f[1]),
+		HostIP:    net.ParseIP(f[2]),
+		HostPort:  uint16(hostPort),
+		GuestIP:   net.ParseIP(f[4]),
+		GuestPort: uint16(guestPort),
+	}, nil
+}
+
+// ListNATPFRules lists the port forwarding rules of the n-th NIC of the
+// machine, which must be attached to NAT, keyed by rule name.
+func ListNATPFRules(vm string, n int) (map[string]PFRule, error) {
+	if err := checkNICIndex(n); err != nil {
+		return nil, err
+	}
+	propMap, err := machineProps(vm)
+	if err != nil {
+		return nil, err
+	}
+	if nic := propMap[fmt.Sprintf("nic%d", n)]; nic != string(NICNetNAT) {
+		return nil, fmt.Errorf("NIC %d of '%s' is not attached to NAT", n, vm)
+	}
+	rules, err := parseNICPFRules(propMap, n)
+	if err != nil {
+		return nil, err
+	}
+	if rules == nil {
+		rules = map[string]PFRule{}
+	}
+	return rules, nil
+}
+
+// parseNICPFRules parses the port forwarding rules of the n-th NIC, keyed by
+// rule name, or nil when there is none.
+func parseNICPFRules(propMap map[string]string, n int) (map[string]PFRule, error) {
+	var rules map[string]PFRule
+	for i := 0; ; i++ {
+		val, ok := propMap[fmt.Sprintf("nic%d.Forwarding(%d)", n, i)]
+		if !ok {
+			break
+		}
+		name, rule, err := parsePFRule(val)
+		if err != nil {
+			return nil, err
+		}
+		if rules == nil {
+			rules = map[string]PFRule{}
+		}
+		rules[name] = rule
+	}
+	return rules, nil
+}
+
+// natPFRule looks up the named port forwarding rule of the n-th NIC of the machine.
+func natPFRule(vm string, n int, ruleName string) (PFRule, error) {
+	rules, err := ListNATPFRules(vm, n)
+	if err != nil {
+		return PFRule{}, err
+	}
+	rule, ok := rules[ruleName]
+	if !ok {
+		return PFRule{}, fmt.Errorf("no port forwarding rule '%s' on NIC %d of '%s'", ruleName, n, vm)
+	}
+	return rule, nil
+}
+
+// hostAddr returns the "host:port" address to reach the rule from the host.
+func (r PFRule) hostAddr() string {
+	host := "127.0.0.1" // the rule listens on all the host interfaces
+	if r.HostIP != nil && !r.HostIP.IsUnspecified() {
+		host = r.HostIP.String()
+	}
+	return net.JoinHostPort(host, strconv.Itoa(int(r.HostPort)))
+}
+
+// ForwardedAddress returns the "host:port" address on the host side of the
+// named port forwarding rule of the n-th NIC of the machine, with 127.0.0.1
+// as host when the rule listens on all the host interfaces.
+func ForwardedAddress(vm string, n int, ruleName string) (string, error) {
+	rule, err := natPFRule(vm, n, ruleName)
+	if err != nil {
+		return "", err
+	}
+	return rule.hostAddr(), nil
+}
+
+// PortForwardRule is a named port forwarding rule.
+type PortForwardRule struct {
+	Name string
+	PFRule
+}
+
+// equal tells whether both rules forward the same way.
+func (r PFRule) equal(o PFRule) bool {
+	return r.Proto == o.Proto && r.HostPort == o.HostPort && r.GuestPort == o.GuestPort &&
+		r.HostIP.Equal(o.HostIP) && r.GuestIP.Equal(o.GuestIP)
+}
+
+// ReconcileNATPortForwards makes the port forwarding rules of the n-th NIC of
+// the machine, which must be attached to NAT, the desired ones: the rules
+// missing from desired are deleted, and the new ones added. Rules are matched
+// by name, a changed rule is deleted then added again. It changes the rules
+// live when the machine is running, and its settings otherwise.
+func ReconcileNATPortForwards(vm string, n int, desired []PortForwardRule) error {
+	want := make(map[string]PFRule, len(desired))
+	for _, r := range desired {
+		if r.Name == "" || strings.Contains(r.Name, ",") {
+			return fmt.Errorf("%w: invalid port forwarding rule name '%s'", ErrInvalidArgument, r.Name)
+		}
+		if _, ok := want[r.Name]; ok {
+			return fmt.Errorf("%w: duplicate port forwarding rule '%s'", ErrInvalidArgument, r.Name)
+		}
+		want[r.Name] = r.PFRule
+	}
+	current, err := ListNATPFRules(vm, n)
+	if err != nil {
+		return err
+	}
+
+	var deletes, adds []string
+	for name, rule := range current {
+		if w, ok := want[name]; !ok || !w.equal(rule) {
+			deletes = append(deletes, name)
+		}
+	}
+	for _, r := range desired {
+		if cur, ok := current[r.Name]; !ok || !cur.equal(r.PFRule) {
+			adds = append(adds, r.Name+","+r.Format())
+		}
+	}
+	if len(deletes)+len(adds) == 0 {
+		return nil
+	}
+	sort.Strings(deletes)
+
+	r, err := isRunning(vm)
+	if err != nil {
+		return err
+	}
+	if r {
+		opt := fmt.Sprintf("natpf%d", n)
+		for _, name := range deletes {
+			if err := Manage().run("controlvm", vm, opt, "delete", name); err != nil {
+				return err
+			}
+		}
+		for _, rule := range adds {
+			if err := Manage().run("controlvm", vm, opt, rule); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := assertMutable(vm); err != nil {
+		return err
+	}
+	opt := fmt.Sprintf("--natpf%d", n)
+	args := []string{"modifyvm", vm}
+	for _, name := range deletes {
+		args = append(args, opt, "delete", name)
+	}
+	for _, rule := range adds {
+		args = append(args, opt, rule)
+	}
+	return Manage().run(args...)
+}
@@ -0,0 +1,79 @@
+package virtualbox
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestForwardedAddress(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		vmInfoOut := ReadTestData("vboxmanage-showvminfo-1.out")
+		anyHostOut := strings.Replace(vmInfoOut, "ssh,tcp,127.0.0.1,2222,,22", "ssh,tcp,,2222,,22", 1)
+		ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(1)
+		ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(anyHostOut, "", nil).Times(1)
+		ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(1)
+		for i := 0; i < 2; i++ {
+			addr, err := ForwardedAddress(VM, 1, "ssh")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if addr != "127.0.0.1:2222" {
+				t.Fatalf("expected 127.0.0.1:2222, got %s", addr)
+			}
+		}
+		if _, err := ForwardedAddress(VM, 1, "http"); err == nil {
+			t.Fatal("expected an error for an unknown rule")
+		}
+	}
+
+	Teardown()
+}
+
+func TestReconcileNATPortForwards(t *testing.T) {
+	Setup(t)
+
+	if err := ReconcileNATPortForwards(VM, 1, []PortForwardRule{{Name: "a,b"}}); !errors.Is(err, ErrInvalidArgument) {
+		t.Fatalf("expected ErrInvalidArgument, got %v", err)
+	}
+	if ManageMock != nil {
+		vmInfoOut := ReadTestData("vboxmanage-showvminfo-poweroff.out")
+		withHTTP := strings.Replace(vmInfoOut, `Forwarding(0)="ssh,tcp,127.0.0.1,2222,,22"`,
+			"Forwarding(0)=\"ssh,tcp,127.0.0.1,2222,,22\"\nForwarding(1)=\"http,tcp,,8080,,80\"", 1)
+		ssh := PortForwardRule{Name: "ssh", PFRule: PFRule{Proto: PFTCP, HostIP: net.ParseIP("127.0.0.1"), HostPort: 2222, GuestPort: 22}}
+		dns := PortForwardRule{Name: "dns", PFRule: PFRule{Proto: PFUDP, HostPort: 5353, GuestPort: 53}}
+		vm := "go-virtualbox" // as listed by 'list runningvms'
+		running.vms = nil
+		gomock.InOrder(
+			ManageMock.EXPECT().runOutErr("showvminfo", vm, "--machinereadable").Return(withHTTP, "", nil).Times(1),
+			ManageMock.EXPECT().runOut("list", "runningvms").Return("", nil).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", vm, "--machinereadable").Return(withHTTP, "", nil).Times(1),
+			ManageMock.EXPECT().run("modifyvm", vm, "--natpf1", "delete", "http", "--natpf1", "dns,udp,,5353,,53").Return(nil).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", vm, "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+			ManageMock.EXPECT().runOut("list", "runningvms").Return(ReadTestData("vboxmanage-list-vms-1.out"), nil).Times(1),
+			ManageMock.EXPECT().run("controlvm", vm, "natpf1", "delete", "ssh").Return(nil).Times(1),
+			ManageMock.EXPECT().run("controlvm", vm, "natpf1", "ssh,tcp,127.0.0.1,2200,,22").Return(nil).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", vm, "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+		)
+		if err := ReconcileNATPortForwards(vm, 1, []PortForwardRule{ssh, dns}); err != nil {
+			t.Fatal(err)
+		}
+		running.vms = nil
+		moved := ssh
+		moved.HostPort = 2200
+		if err := ReconcileNATPortForwards(vm, 1, []PortForwardRule{moved}); err != nil {
+			t.Fatal(err)
+		}
+		// Nothing to change.
+		if err := ReconcileNATPortForwards(vm, 1, []PortForwardRule{ssh}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	Teardown()
+}
@@ -0,0 +1,48 @@
+package virtualbox
+
+import "fmt"
+
+// ProcessPriority is the scheduling priority of the machine process on the host.
+type ProcessPriority string
+
+const (
+	// PriorityDefault leaves the priority to VirtualBox.
+	PriorityDefault = ProcessPriority("default")
+	// PriorityFlat runs all the machine threads at the same priority.
+	PriorityFlat = ProcessPriority("flat")
+	// PriorityLow runs the machine in the background.
+	PriorityLow = ProcessPriority("low")
+	// PriorityNormal runs the machine like any other process.
+	PriorityNormal = ProcessPriority("normal")
+	// PriorityHigh favors the machine over the other processes.
+	PriorityHigh = ProcessPriority("high")
+)
+
+func (p ProcessPriority) validate() error {
+	switch p {
+	case PriorityDefault, PriorityFlat, PriorityLow, PriorityNormal, PriorityHigh:
+		return nil
+	}
+	return fmt.Errorf("invalid process priority: '%s'", p)
+}
+
+// SetProcessPriority changes the process priority of the running machine,
+// until it stops.
+func SetProcessPriority(vm string, priority ProcessPriority) error {
+	if err := priority.validate(); err != nil {
+		return err
+	}
+	return Manage().run("controlvm", vm, "vm-process-priority", string(priority))
+}
+
+// SetDefaultProcessPriority sets the process priority the machine starts
+// with. The machine must not be running.
+func SetDefaultProcessPriority(vm string, priority ProcessPriority) error {
+	if err := priority.validate(); err != nil {
+		return err
+	}
+	if err := assertMutable(vm); err != nil {
+		return err
+	}
+	return Manage().run("modifyvm", vm, "--vm-process-priority", string(priority))
+}
@@ -0,0 +1,31 @@
+package virtualbox
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestProcessPriority(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		vmInfoOut := ReadTestData("vboxmanage-showvminfo-poweroff.out")
+		gomock.InOrder(
+			ManageMock.EXPECT().run("controlvm", VM, "vm-process-priority", "high").Return(nil).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+			ManageMock.EXPECT().run("modifyvm", VM, "--vm-process-priority", "low").Return(nil).Times(1),
+		)
+		if err := SetProcessPriority(VM, PriorityHigh); err != nil {
+			t.Fatal(err)
+		}
+		if err := SetDefaultProcessPriority(VM, PriorityLow); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := SetDefaultProcessPriority(VM, "idle"); err == nil {
+		t.Fatal("expected an error for an invalid priority")
+	}
+
+	Teardown()
+}
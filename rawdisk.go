@@ -0,0 +1,108 @@
+package virtualbox
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+var (
+	reWindowsPhysicalDrive = regexp.MustCompile(`^\\\\\.\\PhysicalDrive\d+$`)
+)
+
+// ErrRawDiskNotConfirmed holds the error message when CreateRawDiskVMDK is called without RawDiskConfirm.
+var ErrRawDiskNotConfirmed = errors.New("raw disk access not confirmed")
+
+// RawDiskOption customizes CreateRawDiskVMDK.
+type RawDiskOption func(*rawDiskConfig)
+
+type rawDiskConfig struct {
+	confirmed bool
+	sudo      bool
+}
+
+// RawDiskConfirm acknowledges that the guest gets direct access to the host
+// device, and can destroy the data on it, or corrupt a file system mounted on
+// the host.
+func RawDiskConfirm() RawDiskOption {
+	return func(cfg *rawDiskConfig) {
+		cfg.confirmed = true
+	}
+}
+
+// RawDiskSudo runs VBoxManage under sudo, when the current user is a sudoer,
+// since reading the raw device usually requires elevation.
+func RawDiskSudo() RawDiskOption {
+	return func(cfg *rawDiskConfig) {
+		cfg.sudo = true
+	}
+}
+
+// checkRawDevice rejects paths which are not host disk devices.
+func checkRawDevice(dev string) error {
+	if runtime.GOOS == osWindows {
+		if !reWindowsPhysicalDrive.MatchString(dev) {
+			return fmt.Errorf("invalid raw device '%s', must be \\\\.\\PhysicalDriveN", dev)
+		}
+		return nil
+	}
+	if !strings.HasPrefix(dev, "/dev/") || filepath.Clean(dev) != dev {
+		return fmt.Errorf("invalid raw device '%s', must be under /dev", dev)
+	}
+	fi, err := os.Stat(dev)
+	if err != nil {
+		return err
+	}
+	if fi.Mode()&os.ModeDevice == 0 {
+		return fmt.Errorf("invalid raw device '%s', not a device", dev)
+	}
+	return nil
+}
+
+// CreateRawDiskVMDK creates a VMDK file at vmdkPath which gives a machine
+// attaching it direct access to the host disk rawDevice, e.g. "/dev/sdb" or
+// `\\.\PhysicalDrive1`, or to the given partitions of it only. This is as
+// dangerous as it sounds, hence RawDiskConfirm is required. Access denied
+// errors wrap os.ErrPermission, see RawDiskSudo.
+func CreateRawDiskVMDK(vmdkPath, rawDevice string, partitions []int, opts ...RawDiskOption) error {
+	var cfg rawDiskConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if !cfg.confirmed {
+		return fmt.Errorf("giving access to '%s': %w", rawDevice, ErrRawDiskNotConfirmed)
+	}
+	if err := checkRawDevice(rawDevice); err != nil {
+		return err
+	}
+	if err := checkWritableDir(filepath.Dir(vmdkPath)); err != nil {
+		return err
+	}
+
+	args := []string{"createmedium", "disk",
+		"--filename", vmdkPath,
+		"--format", "VMDK",
+		"--variant", "RawDisk",
+		"--property", "RawDrive=" + rawDevice,
+	}
+	if len(partitions) > 0 {
+		parts := make([]string, len(partitions))
+		for i, p := range partitions {
+			if p < 1 {
+				return fmt.Errorf("invalid partition number %d", p)
+			}
+			parts[i] = strconv.Itoa(p)
+		}
+		args = append(args, "--property", "Partitions="+strings.Join(parts, ","))
+	}
+	_, stderr, err := Manage().setOpts(sudo(cfg.sudo)).runOutErr(args...)
+	if err != nil && rePermissionDenied.MatchString(stderr) {
+		return fmt.Errorf("reading '%s': %w", rawDevice, os.ErrPermission)
+	}
+	return err
+}
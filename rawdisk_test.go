@@ -0,0 +1,43 @@
+package virtualbox
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestCreateRawDiskVMDK(t *testing.T) {
+	Setup(t)
+
+	if err := CreateRawDiskVMDK("raw.vmdk", "/dev/null", nil); !errors.Is(err, ErrRawDiskNotConfirmed) {
+		t.Fatalf("expected ErrRawDiskNotConfirmed, got %v", err)
+	}
+	if err := CreateRawDiskVMDK("raw.vmdk", "/etc/passwd", nil, RawDiskConfirm()); err == nil {
+		t.Fatal("expected an error for a path which is not a device")
+	}
+	if ManageMock != nil && runtime.GOOS != osWindows {
+		// /dev/null stands for a disk device.
+		vmdk := filepath.Join(os.TempDir(), "raw.vmdk")
+		denied := "VBoxManage: error: VMDK: could not open raw partition file '/dev/null' (VERR_ACCESS_DENIED)\n"
+		gomock.InOrder(
+			ManageMock.EXPECT().setOpts(gomock.Any()).Return(ManageMock).Times(1),
+			ManageMock.EXPECT().runOutErr("createmedium", "disk", "--filename", vmdk, "--format", "VMDK", "--variant", "RawDisk",
+				"--property", "RawDrive=/dev/null", "--property", "Partitions=1,5").Return("", "", nil).Times(1),
+			ManageMock.EXPECT().setOpts(gomock.Any()).Return(ManageMock).Times(1),
+			ManageMock.EXPECT().runOutErr("createmedium", "disk", "--filename", vmdk, "--format", "VMDK", "--variant", "RawDisk",
+				"--property", "RawDrive=/dev/null").Return("", denied, errors.New("exit status 1")).Times(1),
+		)
+		if err := CreateRawDiskVMDK(vmdk, "/dev/null", []int{1, 5}, RawDiskConfirm(), RawDiskSudo()); err != nil {
+			t.Fatal(err)
+		}
+		if err := CreateRawDiskVMDK(vmdk, "/dev/null", nil, RawDiskConfirm()); !errors.Is(err, os.ErrPermission) {
+			t.Fatalf("expected os.ErrPermission, got %v", err)
+		}
+	}
+
+	Teardown()
+}
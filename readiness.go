@@ -0,0 +1,93 @@
+package virtualbox
+
+import (
+	"fmt"
+	"net"
+	"time"
+)
+
+// ReadinessProbe tells whether a machine is ready for use, by whatever
+// definition suits the workload. Ready returns false while the machine is not
+// ready yet, and an error only when waiting further is pointless.
+type ReadinessProbe interface {
+	Ready(vm string) (bool, error)
+}
+
+// GuestAdditionsProbe is ready once the Guest Additions run in the guest.
+type GuestAdditionsProbe struct{}
+
+// Ready implements ReadinessProbe.
+func (GuestAdditionsProbe) Ready(vm string) (bool, error) {
+	// The property is unset until the Guest Additions report in.
+	v, err := GetGuestProperty(vm, "/VirtualBox/GuestAdd/Version")
+	return err == nil && v != "", nil
+}
+
+// GuestIPProbe is ready once the guest reports an IPv4 address on the NIC-th
+// NIC (starting at 1). It requires the Guest Additions.
+type GuestIPProbe struct {
+	NIC int
+}
+
+// Ready implements ReadinessProbe.
+func (p GuestIPProbe) Ready(vm string) (bool, error) {
+	if err := checkNICIndex(p.NIC); err != nil {
+		return false, err
+	}
+	// The guest properties number the NICs from 0.
+	v, err := GetGuestProperty(vm, fmt.Sprintf("/VirtualBox/GuestInfo/Net/%d/V4/IP", p.NIC-1))
+	return err == nil && net.ParseIP(v) != nil, nil
+}
+
+// TCPPortProbe is ready once a TCP connection to Addr, e.g. the host side of
+// a port forwarding rule, succeeds.
+type TCPPortProbe struct {
+	Addr        string        // host:port
+	DialTimeout time.Duration // per attempt, 1s if zero
+}
+
+// Ready implements ReadinessProbe.
+func (p TCPPortProbe) Ready(string) (bool, error) {
+	timeout := p.DialTimeout
+	if timeout == 0 {
+		timeout = 1 * time.Second
+	}
+	conn, err := net.DialTimeout("tcp", p.Addr, timeout)
+	if err != nil {
+		return false, nil
+	}
+	_ = conn.Close()
+	return true, nil
+}
+
+// WaitReady polls the probe until the machine is ready, the probe fails or the
+// timeout expires.
+func WaitReady(vm string, probe ReadinessProbe, timeout time.Duration, opts ...WaitOptions) error {
+	return waitOptions(timeout, opts).poll(func() (bool, error) {
+		return probe.Ready(vm)
+	})
+}
+
+// WaitForForwardedPort waits until the host side of the named TCP port
+// forwarding rule of the n-th NIC of the machine accepts connections, e.g. to
+// wait for the guest SSH server. It retries with a backoff until the timeout
+// expires.
+func WaitForForwardedPort(vm string, n int, ruleName string, timeout time.Duration, opts ...WaitOptions) error {
+	rule, err := natPFRule(vm, n, ruleName)
+	if err != nil {
+		return err
+	}
+	if rule.Proto != PFTCP {
+		return fmt.Errorf("port forwarding rule '%s' is not TCP", ruleName)
+	}
+	probe := TCPPortProbe{Addr: rule.hostAddr()}
+
+	err = waitOptions(timeout, opts).poll(func() (bool, error) {
+		ready, _ := probe.Ready(vm)
+		return ready, nil
+	})
+	if err != nil {
+		return fmt.Errorf("%s not reachable: %w", probe.Addr, err)
+	}
+	return nil
+}
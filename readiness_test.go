@@ -0,0 +1,69 @@
+package virtualbox
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestGuestIPProbe(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		gomock.InOrder(
+			ManageMock.EXPECT().isGuest().Return(false),
+			ManageMock.EXPECT().runOut("guestproperty", "get", VM, "/VirtualBox/GuestInfo/Net/0/V4/IP").Return("No value set!", nil).Times(1),
+			ManageMock.EXPECT().isGuest().Return(false),
+			ManageMock.EXPECT().runOut("guestproperty", "get", VM, "/VirtualBox/GuestInfo/Net/0/V4/IP").Return("Value: 10.0.2.15", nil).Times(1),
+		)
+		probe := GuestIPProbe{NIC: 1}
+		if ready, err := probe.Ready(VM); err != nil || ready {
+			t.Fatalf("expected not ready yet, got %v, %v", ready, err)
+		}
+		if err := WaitReady(VM, probe, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	Teardown()
+}
+
+func TestTCPPortProbe(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	if err := WaitReady(VM, TCPPortProbe{Addr: addr}, 0); err != nil {
+		t.Fatal(err)
+	}
+	_ = l.Close()
+	if err := WaitReady(VM, TCPPortProbe{Addr: addr}, 0); err != ErrStateTimeout {
+		t.Fatalf("expected ErrStateTimeout once closed, got %v", err)
+	}
+}
+
+func TestWaitForForwardedPort(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer l.Close()
+		_, port, _ := net.SplitHostPort(l.Addr().String())
+		vmInfoOut := strings.Replace(ReadTestData("vboxmanage-showvminfo-1.out"), "127.0.0.1,2222,", "127.0.0.1,"+port+",", 1)
+		ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(2)
+		if err := WaitForForwardedPort(VM, 1, "ssh", 0); err != nil {
+			t.Fatal(err)
+		}
+		if err := WaitForForwardedPort(VM, 1, "http", 0); err == nil {
+			t.Fatal("expected an error for an unknown rule")
+		}
+	}
+
+	Teardown()
+}
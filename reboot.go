@@ -0,0 +1,115 @@
+package virtualbox
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RebootOption customizes Reboot.
+type RebootOption func(*rebootConfig)
+
+type rebootConfig struct {
+	creds *GuestCredentials
+	wait  []WaitOptions
+}
+
+// RebootAs gives the guest account which runs the reboot command for graceful
+// reboots. It must be allowed to reboot the guest, e.g. root or an
+// Administrator.
+func RebootAs(creds GuestCredentials) RebootOption {
+	return func(cfg *rebootConfig) {
+		cfg.creds = &creds
+	}
+}
+
+// RebootWait tunes how a graceful reboot polls for the guest to cycle.
+func RebootWait(opts WaitOptions) RebootOption {
+	return func(cfg *rebootConfig) {
+		cfg.wait = append(cfg.wait, opts)
+	}
+}
+
+// Reboot restarts the running machine. With graceful, the Guest Additions
+// running and a guest account given with RebootAs, the guest is asked to
+// reboot itself through guest control, and Reboot waits up to timeout for the
+// Guest Additions to go down and to come back up. A guest which does not go
+// down in time is hard reset. Otherwise, the machine is hard reset right away,
+// like the reset button would.
+func Reboot(vm string, graceful bool, timeout time.Duration, opts ...RebootOption) error {
+	var cfg rebootConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	r, err := isRunning(vm)
+	if err != nil {
+		return err
+	}
+	if !r {
+		return ErrMachineNotRunning
+	}
+	if graceful && cfg.creds != nil {
+		if ready, _ := (GuestAdditionsProbe{}).Ready(vm); ready {
+			return guestReboot(vm, *cfg.creds, waitOptions(timeout, cfg.wait))
+		}
+	}
+	return Manage().run("controlvm", vm, "reset")
+}
+
+// guestReboot runs the reboot command of the guest OS and waits for the guest
+// to cycle. Guest properties such as the IP address usually survive a reboot,
+// but the Guest Additions run level drops to none while the guest is down.
+func guestReboot(vm string, creds GuestCredentials, wait WaitOptions) error {
+	level, err := guestRunLevel(vm)
+	if err != nil {
+		return err
+	}
+	if level > 2 {
+		// The desktop level needs a user logging in again.
+		level = 2
+	}
+	exe, args := "/sbin/reboot", []string{}
+	if product, _ := GetGuestProperty(vm, "/VirtualBox/GuestInfo/OS/Product"); strings.HasPrefix(product, "Windows") {
+		exe, args = `C:\Windows\System32\shutdown.exe`, []string{"/r", "/t", "0"}
+	}
+	// The session may be torn down by the reboot before the command returns,
+	// so an error only matters if the guest does not go down.
+	runErr := withGuestSession(vm, creds, func(gs *GuestSession) error {
+		_, err := gs.Run(exe, args...)
+		return err
+	})
+
+	down := false
+	err = wait.poll(func() (bool, error) {
+		l, err := guestRunLevel(vm)
+		if err != nil {
+			return false, err
+		}
+		if l == 0 {
+			down = true
+			return false, nil
+		}
+		return down && l >= level, nil
+	})
+	if err != ErrStateTimeout {
+		return err
+	}
+	if down {
+		return fmt.Errorf("'%s' did not come back up after rebooting: %w", vm, err)
+	}
+	Debug("'%s' did not go down after the reboot command (%v), resetting it", vm, runErr)
+	return Manage().run("controlvm", vm, "reset")
+}
+
+// guestRunLevel reads the Guest Additions run level of the machine: 0 when
+// they are not running, 1 for the kernel drivers, 2 for the services and 3
+// for the desktop integration.
+func guestRunLevel(vm string) (int, error) {
+	props, err := machineProps(vm)
+	if err != nil {
+		return 0, err
+	}
+	level, _ := strconv.Atoi(props["GuestAdditionsRunLevel"])
+	return level, nil
+}
@@ -0,0 +1,63 @@
+package virtualbox
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestReboot(t *testing.T) {
+	Setup(t)
+
+	running.vms = nil
+	if ManageMock != nil {
+		listRunningVmsOut := ReadTestData("vboxmanage-list-vms-1.out")
+		vmInfoOut := strings.Replace(ReadTestData("vboxmanage-showvminfo-1.out"), `VMState="saved"`, `VMState="running"`, 1)
+		level := func(l int) string { return vmInfoOut + fmt.Sprintf("GuestAdditionsRunLevel=%d\n", l) }
+		ManageMock.EXPECT().isGuest().Return(false).AnyTimes()
+		gomock.InOrder(
+			ManageMock.EXPECT().runOut("list", "runningvms").Return(listRunningVmsOut, nil).Times(1),
+			ManageMock.EXPECT().runOut("guestproperty", "get", "Ubuntu", "/VirtualBox/GuestAdd/Version").Return("Value: 6.1.16", nil).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", "Ubuntu", "--machinereadable").Return(level(3), "", nil).Times(1),
+			ManageMock.EXPECT().runOut("guestproperty", "get", "Ubuntu", "/VirtualBox/GuestInfo/OS/Product").Return("Value: Linux", nil).Times(1),
+			ManageMock.EXPECT().runOutErr("guestcontrol", "Ubuntu", "run", "--username", "root",
+				"--exe", "/sbin/reboot", "--wait-stdout", "--", "/sbin/reboot").Return("", "", nil).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", "Ubuntu", "--machinereadable").Return(level(3), "", nil).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", "Ubuntu", "--machinereadable").Return(level(0), "", nil).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", "Ubuntu", "--machinereadable").Return(level(1), "", nil).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", "Ubuntu", "--machinereadable").Return(level(2), "", nil).Times(1),
+
+			// The guest ignores the reboot command.
+			ManageMock.EXPECT().runOut("guestproperty", "get", "Ubuntu", "/VirtualBox/GuestAdd/Version").Return("Value: 6.1.16", nil).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", "Ubuntu", "--machinereadable").Return(level(2), "", nil).Times(1),
+			ManageMock.EXPECT().runOut("guestproperty", "get", "Ubuntu", "/VirtualBox/GuestInfo/OS/Product").Return("Value: Linux", nil).Times(1),
+			ManageMock.EXPECT().runOutErr("guestcontrol", "Ubuntu", "run", "--username", "root",
+				"--exe", "/sbin/reboot", "--wait-stdout", "--", "/sbin/reboot").Return("", "", errors.New("exit status 1")).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", "Ubuntu", "--machinereadable").Return(level(2), "", nil).MinTimes(1),
+			ManageMock.EXPECT().run("controlvm", "Ubuntu", "reset").Return(nil).Times(1),
+
+			ManageMock.EXPECT().run("controlvm", "Ubuntu", "reset").Return(nil).Times(1),
+		)
+		if err := Reboot("Ubuntu", true, 10*time.Second, RebootAs(GuestCredentials{Username: "root"})); err != nil {
+			t.Fatal(err)
+		}
+		running.at = time.Now() // keep using the listed machines
+		fast := RebootWait(WaitOptions{Interval: time.Millisecond})
+		if err := Reboot("Ubuntu", true, 10*time.Millisecond, RebootAs(GuestCredentials{Username: "root"}), fast); err != nil {
+			t.Fatal(err)
+		}
+		running.at = time.Now()
+		if err := Reboot("Ubuntu", false, 0); err != nil {
+			t.Fatal(err)
+		}
+		if err := Reboot("Stopped", false, 0); err != ErrMachineNotRunning {
+			t.Fatalf("expected ErrMachineNotRunning, got %v", err)
+		}
+	}
+
+	Teardown()
+}
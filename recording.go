@@ -0,0 +1,79 @@
+package virtualbox
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	reVideoResolution = regexp.MustCompile(`^[1-9]\d*x[1-9]\d*$`)
+)
+
+// RecordingConfig holds the screen recording settings of a machine. Zero
+// fields are left unchanged.
+type RecordingConfig struct {
+	Enabled    bool
+	File       string // path of the WebM video file
+	Resolution string // e.g. "1024x768"
+	FPS        int    // frames per second
+	Screens    []int  // numbers of the screens to record, starting at 0
+}
+
+// ConfigureRecording applies the screen recording settings of the machine.
+// When the machine is running, only recording can be toggled, live; the other
+// settings require it to be stopped.
+func ConfigureRecording(vm string, cfg RecordingConfig) error {
+	if cfg.Resolution != "" && !reVideoResolution.MatchString(cfg.Resolution) {
+		return fmt.Errorf("invalid recording resolution '%s', must be <width>x<height>", cfg.Resolution)
+	}
+	if cfg.FPS < 0 || cfg.FPS > 240 {
+		return fmt.Errorf("invalid recording frame rate %d, must be in 1--240", cfg.FPS)
+	}
+	for _, n := range cfg.Screens {
+		if n < 0 || n > 63 {
+			return fmt.Errorf("invalid recording screen %d, must be in 0--63", n)
+		}
+	}
+
+	m, err := GetMachine(vm)
+	if err != nil {
+		return err
+	}
+	if m.State == Running || m.State == Paused {
+		if cfg.File != "" || cfg.Resolution != "" || cfg.FPS != 0 || len(cfg.Screens) > 0 {
+			return fmt.Errorf("recording settings of '%s' can only be toggled: %w", vm, ErrMachineRunning)
+		}
+		return Manage().run("controlvm", vm, "recording", bool2string(cfg.Enabled))
+	}
+	if err := assertMutable(vm); err != nil {
+		return err
+	}
+
+	// VirtualBox 7.0 renamed the options, deprecating the old names.
+	opt := func(name string) string { return "--recording" + name }
+	if ok, err := versionAtLeast(7, 0); err != nil {
+		return err
+	} else if ok {
+		opt = func(name string) string { return "--recording-" + strings.Replace(name, "video", "video-", 1) }
+	}
+	args := []string{"modifyvm", vm, "--recording", bool2string(cfg.Enabled)}
+	if cfg.File != "" {
+		args = append(args, opt("file"), cfg.File)
+	}
+	if cfg.Resolution != "" {
+		args = append(args, opt("videores"), cfg.Resolution)
+	}
+	if cfg.FPS != 0 {
+		args = append(args, opt("videofps"), strconv.Itoa(cfg.FPS))
+	}
+	if len(cfg.Screens) > 0 {
+		screens := make([]string, len(cfg.Screens))
+		for i, n := range cfg.Screens {
+			screens[i] = strconv.Itoa(n)
+		}
+		args = append(args, opt("screens"), strings.Join(screens, ","))
+	}
+	return Manage().run(args...)
+}
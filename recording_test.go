@@ -0,0 +1,49 @@
+package virtualbox
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestConfigureRecording(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		vmInfoOut := ReadTestData("vboxmanage-showvminfo-poweroff.out")
+		running := strings.Replace(vmInfoOut, `VMState="poweroff"`, `VMState="running"`, 1)
+		cfg := RecordingConfig{Enabled: true, File: "/tmp/ui.webm", Resolution: "1024x768", FPS: 25, Screens: []int{0, 1}}
+		gomock.InOrder(
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(2),
+			ManageMock.EXPECT().runOut("--version").Return("7.0.4r154605\n", nil).Times(1),
+			ManageMock.EXPECT().run("modifyvm", VM, "--recording", "on", "--recording-file", "/tmp/ui.webm",
+				"--recording-video-res", "1024x768", "--recording-video-fps", "25", "--recording-screens", "0,1").Return(nil).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(2),
+			ManageMock.EXPECT().runOut("--version").Return("6.1.30r148432\n", nil).Times(1),
+			ManageMock.EXPECT().run("modifyvm", VM, "--recording", "on", "--recordingfile", "/tmp/ui.webm",
+				"--recordingvideores", "1024x768", "--recordingvideofps", "25", "--recordingscreens", "0,1").Return(nil).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(running, "", nil).Times(1),
+			ManageMock.EXPECT().run("controlvm", VM, "recording", "off").Return(nil).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(running, "", nil).Times(1),
+		)
+		if err := ConfigureRecording(VM, cfg); err != nil {
+			t.Fatal(err)
+		}
+		if err := ConfigureRecording(VM, cfg); err != nil {
+			t.Fatal(err)
+		}
+		if err := ConfigureRecording(VM, RecordingConfig{}); err != nil {
+			t.Fatal(err)
+		}
+		if err := ConfigureRecording(VM, cfg); !errors.Is(err, ErrMachineRunning) {
+			t.Fatalf("expected ErrMachineRunning, got %v", err)
+		}
+	}
+	if err := ConfigureRecording(VM, RecordingConfig{Resolution: "1024*768"}); err == nil {
+		t.Fatal("expected an error for an invalid resolution")
+	}
+
+	Teardown()
+}
@@ -0,0 +1,226 @@
+package virtualbox
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// runningPollInterval is how long a 'list runningvms' result is shared between
+// concurrent waiters: WaitUntilRunning does not poll VBoxManage more often.
+const runningPollInterval = 1 * time.Second
+
+var running struct {
+	sync.Mutex
+	at  time.Time
+	vms map[string]string // UUID -> name
+}
+
+// runningMachines lists the running (or paused) machines, keyed by UUID. A
+// result younger than runningPollInterval is reused, so that many goroutines
+// waiting on many machines only cost one subprocess per poll period.
+func runningMachines() (map[string]string, error) {
+	running.Lock()
+	defer running.Unlock()
+	if running.vms != nil && time.Since(running.at) < runningPollInterval {
+		return running.vms, nil
+	}
+	out, err := Manage().runOut("list", "runningvms")
+	if err != nil {
+		return nil, err
+	}
+	vms := map[string]string{}
+	s := bufio.NewScanner(strings.NewReader(out))
+	for s.Scan() {
+		res := reVMNameUUID.FindStringSubmatch(s.Text())
+		if res == nil {
+			continue
+		}
+		vms[res[2]] = res[1]
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	running.vms, running.at = vms, time.Now()
+	return vms, nil
+}
+
+// isRunning tells whether the machine, given by name or UUID, has a running process.
+func isRunning(vm string) (bool, error) {
+	vms, err := runningMachines()
+	if err != nil {
+		return false, err
+	}
+	for uuid, name := range vms {
+		if vm == uuid || vm == name {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// WaitUntilRunning waits until the machine process is started (running is
+// true) or gone (running is false), or the timeout expires. Unlike
+// WaitUntilState, it relies on 'list runningvms' rather than on a full
+// showvminfo parse, and concurrent waiters share each poll, which makes it
+// the cheaper choice when waiting on many machines at once.
+func WaitUntilRunning(vm string, running bool, timeout time.Duration, opts ...WaitOptions) error {
+	return waitOptions(timeout, opts).poll(func() (bool, error) {
+		r, err := isRunning(vm)
+		if err != nil {
+			return false, err
+		}
+		return r == running, nil
+	})
+}
+
+// ListRunningMachines lists the machines which have a running (or paused)
+// process.
+func ListRunningMachines() ([]*Machine, error) {
+	vms, err := runningMachines()
+	if err != nil {
+		return nil, err
+	}
+	uuids := make([]string, 0, len(vms))
+	for uuid := range vms {
+		uuids = append(uuids, uuid)
+	}
+	sort.Strings(uuids)
+	ms := make([]*Machine, 0, len(uuids))
+	for _, uuid := range uuids {
+		m, err := GetMachine(uuid)
+		if err == ErrMachineNotExist {
+			continue // unregistered in between
+		}
+		if err != nil {
+			return nil, err
+		}
+		ms = append(ms, m)
+	}
+	return ms, nil
+}
+
+// StopAll stops all the running machines in parallel, e.g. before the host
+// shuts down. With graceful, each guest is sent the ACPI power button and is
+// powered off if still running after timeout; otherwise, or when paused, the
+// machine state is saved. It returns one error per machine which could not be
+// stopped.
+func StopAll(graceful bool, timeout time.Duration) []error {
+	vms, err := runningMachines()
+	if err != nil {
+		return []error{err}
+	}
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	for uuid, name := range vms {
+		wg.Add(1)
+		go func(uuid, name string) {
+			defer wg.Done()
+			if err := stopMachine(uuid, graceful, timeout); err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("stopping '%s': %w", name, err))
+				mu.Unlock()
+			}
+		}(uuid, name)
+	}
+	wg.Wait()
+	return errs
+}
+
+func stopMachine(vm string, graceful bool, timeout time.Duration) error {
+	m, err := GetMachine(vm)
+	if err == ErrMachineNotExist {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !graceful || m.State == Paused {
+		// A paused guest cannot react to the ACPI power button.
+		return Manage().run("controlvm", vm, "savestate")
+	}
+	if err := Manage().run("controlvm", vm, "acpipowerbutton"); err != nil {
+		return err
+	}
+	if err := WaitUntilRunning(vm, false, timeout); err != ErrStateTimeout {
+		return err
+	}
+	return Manage().run("controlvm", vm, "poweroff")
+}
+
+// RunningMachine is a machine which has a running (or paused) process.
+type RunningMachine struct {
+	Name string
+	UUID string
+	PID  int // of the VirtualBoxVM/VBoxHeadless process, 0 when unknown
+}
+
+// procRoot is where the host process list is read from.
+var procRoot = "/proc"
+
+// ListRunningMachineDetails lists the machines which have a running (or
+// paused) process, sorted by name, along with the PID of that process. The PID
+// is found by looking for the process started with '--startvm <uuid>' in the
+// host process list, which is only available on Linux; elsewhere, or when the
+// process cannot be found, the PID is 0.
+func ListRunningMachineDetails() ([]RunningMachine, error) {
+	vms, err := runningMachines()
+	if err != nil {
+		return nil, err
+	}
+	pids := vmProcesses()
+	rms := make([]RunningMachine, 0, len(vms))
+	for uuid, name := range vms {
+		pid := pids[uuid]
+		if pid == 0 {
+			pid = pids[name]
+		}
+		rms = append(rms, RunningMachine{Name: name, UUID: uuid, PID: pid})
+	}
+	sort.Slice(rms, func(i, j int) bool {
+		if rms[i].Name != rms[j].Name {
+			return rms[i].Name < rms[j].Name
+		}
+		return rms[i].UUID < rms[j].UUID
+	})
+	return rms, nil
+}
+
+// vmProcesses maps the '--startvm' argument of the machine processes found in
+// procRoot to their PID. Errors are ignored: processes come and go while
+// reading, and the PID is best effort anyway.
+func vmProcesses() map[string]int {
+	pids := map[string]int{}
+	entries, err := ioutil.ReadDir(procRoot)
+	if err != nil {
+		return pids
+	}
+	for _, e := range entries {
+		pid, err := strconv.Atoi(e.Name())
+		if err != nil || !e.IsDir() {
+			continue
+		}
+		b, err := ioutil.ReadFile(filepath.Join(procRoot, e.Name(), "cmdline")) // #nosec
+		if err != nil {
+			continue
+		}
+		args := strings.Split(strings.TrimRight(string(b), "\x00"), "\x00")
+		for i := 1; i < len(args)-1; i++ {
+			if args[i] == "--startvm" || args[i] == "-startvm" || args[i] == "-s" {
+				pids[args[i+1]] = pid
+				break
+			}
+		}
+	}
+	return pids
+}
@@ -0,0 +1,100 @@
+package virtualbox
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestWaitUntilRunning(t *testing.T) {
+	Setup(t)
+
+	running.vms = nil // drop any result cached by a previous test
+	if ManageMock != nil {
+		listRunningVmsOut := ReadTestData("vboxmanage-list-vms-1.out")
+		ManageMock.EXPECT().runOut("list", "runningvms").Return(listRunningVmsOut, nil).Times(1)
+		// Both waits are served by the same 'list runningvms' call.
+		if err := WaitUntilRunning("Ubuntu", true, 0); err != nil {
+			t.Fatal(err)
+		}
+		if err := WaitUntilRunning("go-virtualbox", true, 0); err != nil {
+			t.Fatal(err)
+		}
+		if err := WaitUntilRunning("Ubuntu", false, 0); err != ErrStateTimeout {
+			t.Fatalf("expected ErrStateTimeout, got %v", err)
+		}
+	}
+
+	Teardown()
+}
+
+func TestStopAll(t *testing.T) {
+	Setup(t)
+
+	running.vms = nil
+	if ManageMock != nil {
+		listRunningVmsOut := ReadTestData("vboxmanage-list-vms-1.out")
+		vmInfoOut := strings.Replace(ReadTestData("vboxmanage-showvminfo-1.out"), `VMState="saved"`, `VMState="running"`, 1)
+		ManageMock.EXPECT().runOut("list", "runningvms").Return(listRunningVmsOut, nil).Times(1)
+		ManageMock.EXPECT().runOutErr("showvminfo", gomock.Any(), "--machinereadable").Return(vmInfoOut, "", nil).Times(2)
+		ManageMock.EXPECT().run("controlvm", "2e16b1fc-675d-4a7a-a9a1-e89a8bde7874", "savestate").Return(nil).Times(1)
+		ManageMock.EXPECT().run("controlvm", "def44546-e3da-4902-8d15-b91c99c80cbc", "savestate").Return(errors.New("exit status 1")).Times(1)
+
+		errs := StopAll(false, 0)
+		if len(errs) != 1 || !strings.Contains(errs[0].Error(), "'go-virtualbox'") {
+			t.Fatalf("expected one error for go-virtualbox, got %v", errs)
+		}
+	}
+
+	Teardown()
+}
+
+func TestListRunningMachineDetails(t *testing.T) {
+	Setup(t)
+
+	running.vms = nil
+	if ManageMock != nil {
+		root, err := ioutil.TempDir("", "go-virtualbox-test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(root)
+		procs := map[string]string{
+			"1234": "/usr/lib/virtualbox/VBoxHeadless\x00--comment\x00Ubuntu\x00--startvm\x002e16b1fc-675d-4a7a-a9a1-e89a8bde7874\x00--vrde\x00config\x00",
+			"42":   "/sbin/init\x00",
+			"self": "",
+		}
+		for pid, cmdline := range procs {
+			if err := os.Mkdir(filepath.Join(root, pid), 0700); err != nil {
+				t.Fatal(err)
+			}
+			if err := ioutil.WriteFile(filepath.Join(root, pid, "cmdline"), []byte(cmdline), 0600); err != nil {
+				t.Fatal(err)
+			}
+		}
+		defer func(old string) { procRoot = old }(procRoot)
+		procRoot = root
+
+		listRunningVmsOut := ReadTestData("vboxmanage-list-vms-1.out")
+		ManageMock.EXPECT().runOut("list", "runningvms").Return(listRunningVmsOut, nil).Times(1)
+		rms, err := ListRunningMachineDetails()
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := []RunningMachine{
+			{Name: "Ubuntu", UUID: "2e16b1fc-675d-4a7a-a9a1-e89a8bde7874", PID: 1234},
+			{Name: "go-virtualbox", UUID: "def44546-e3da-4902-8d15-b91c99c80cbc"},
+		}
+		if !reflect.DeepEqual(rms, expected) {
+			t.Fatalf("expected %+v, got %+v", expected, rms)
+		}
+	}
+
+	Teardown()
+}
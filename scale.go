@@ -0,0 +1,107 @@
+package virtualbox
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var reCPUAlreadyInState = regexp.MustCompile(`(?i)already (attached|plugged)|not (attached|plugged)`)
+
+// ScaleMachine brings the machine to the given number of CPUs and memory size
+// in MB, a zero value leaving it unchanged. A stopped machine is modified. A
+// running one is scaled live: CPUs are plugged or unplugged, which requires CPU
+// hotplug on and at most the CPU count of the machine, and memory is given back
+// to the host with the memory balloon, which requires the Guest Additions and
+// cannot go above the memory of the machine. The changes which cannot be made
+// live are listed in an error wrapping ErrMachineRunning, after the others
+// have been applied.
+func ScaleMachine(vm string, cpus, memoryMB int) error {
+	if cpus < 0 || memoryMB < 0 {
+		return fmt.Errorf("%w: %d CPUs and %dMB of memory", ErrInvalidArgument, cpus, memoryMB)
+	}
+	props, err := machineProps(vm)
+	if err != nil {
+		return err
+	}
+	switch MachineState(props["VMState"]) {
+	case Running, Paused:
+	default:
+		return scaleStopped(vm, props, cpus, memoryMB)
+	}
+
+	var deferred []string
+	if cpus != 0 {
+		max, _ := strconv.Atoi(props["cpus"])
+		switch {
+		case props["cpuhotplug"] != "on":
+			deferred = append(deferred, fmt.Sprintf("%d CPUs (CPU hotplug is off)", cpus))
+		case cpus > max:
+			deferred = append(deferred, fmt.Sprintf("%d CPUs (above the %d hotpluggable ones)", cpus, max))
+		default:
+			if err := plugCPUs(vm, cpus, max); err != nil {
+				return err
+			}
+		}
+	}
+	if memoryMB != 0 {
+		memory, _ := strconv.Atoi(props["memory"])
+		ready, _ := GuestAdditionsProbe{}.Ready(vm)
+		switch {
+		case memoryMB > memory:
+			deferred = append(deferred, fmt.Sprintf("%dMB of memory (above the %dMB of the machine)", memoryMB, memory))
+		case !ready:
+			deferred = append(deferred, fmt.Sprintf("%dMB of memory (Guest Additions not running)", memoryMB))
+		case strconv.Itoa(memory-memoryMB) != props["GuestMemoryBalloon"]:
+			if err := Manage().run("controlvm", vm, "guestmemoryballoon", strconv.Itoa(memory-memoryMB)); err != nil {
+				return err
+			}
+		}
+	}
+	if len(deferred) > 0 {
+		return fmt.Errorf("cannot scale '%s' live to %s: %w", vm, strings.Join(deferred, " and "), ErrMachineRunning)
+	}
+	return nil
+}
+
+// plugCPUs leaves the first n of the max CPUs of the running machine plugged.
+// VirtualBox does not report which CPUs are plugged, so each one is plugged or
+// unplugged, ignoring those already in the right state. CPU 0 always stays.
+func plugCPUs(vm string, n, max int) error {
+	for id := max - 1; id >= 1; id-- {
+		action := "plugcpu"
+		if id >= n {
+			action = "unplugcpu"
+		}
+		_, stderr, err := Manage().runOutErr("controlvm", vm, action, strconv.Itoa(id))
+		if err != nil && !reCPUAlreadyInState.MatchString(stderr) {
+			return err
+		}
+	}
+	return nil
+}
+
+func scaleStopped(vm string, props map[string]string, cpus, memoryMB int) error {
+	if cpus > 1 && props["ioapic"] != "on" {
+		return fmt.Errorf("the I/O APIC of '%s' is off: %w", vm, ErrIOAPICRequired)
+	}
+	if err := assertMutable(vm); err != nil {
+		return err
+	}
+	args := []string{"modifyvm", vm}
+	if cpus != 0 {
+		args = append(args, "--cpus", strconv.Itoa(cpus))
+	}
+	if memoryMB != 0 {
+		args = append(args, "--memory", strconv.Itoa(memoryMB))
+		if b := props["GuestMemoryBalloon"]; b != "" && b != "0" {
+			// A balloon left from a live scaling would eat into the new size.
+			args = append(args, "--guestmemoryballoon", "0")
+		}
+	}
+	if len(args) == 2 {
+		return nil
+	}
+	return Manage().run(args...)
+}
@@ -0,0 +1,57 @@
+package virtualbox
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestScaleMachine(t *testing.T) {
+	Setup(t)
+
+	if err := ScaleMachine(VM, -1, 0); !errors.Is(err, ErrInvalidArgument) {
+		t.Fatalf("expected ErrInvalidArgument, got %v", err)
+	}
+	if ManageMock != nil {
+		vmInfoOut := ReadTestData("vboxmanage-showvminfo-poweroff.out")
+		running := strings.Replace(vmInfoOut, `VMState="poweroff"`, `VMState="running"`, 1)
+		hotplug := strings.Replace(running, "cpus=1", "cpus=4\ncpuhotplug=\"on\"", 1)
+		cpuErr := errors.New("exit status 1")
+		ManageMock.EXPECT().isGuest().Return(false).AnyTimes()
+		gomock.InOrder(
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+			ManageMock.EXPECT().run("modifyvm", VM, "--cpus", "2", "--memory", "2048").Return(nil).Times(1),
+
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(hotplug, "", nil).Times(1),
+			ManageMock.EXPECT().runOutErr("controlvm", VM, "unplugcpu", "3").Return("", "", nil).Times(1),
+			ManageMock.EXPECT().runOutErr("controlvm", VM, "unplugcpu", "2").Return("", "VBoxManage: error: CPU 2 is not attached", cpuErr).Times(1),
+			ManageMock.EXPECT().runOutErr("controlvm", VM, "plugcpu", "1").Return("", "", nil).Times(1),
+			ManageMock.EXPECT().runOut("guestproperty", "get", VM, "/VirtualBox/GuestAdd/Version").Return("Value: 6.1.16", nil).Times(1),
+			ManageMock.EXPECT().run("controlvm", VM, "guestmemoryballoon", "512").Return(nil).Times(1),
+
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(running, "", nil).Times(1),
+			ManageMock.EXPECT().runOut("guestproperty", "get", VM, "/VirtualBox/GuestAdd/Version").Return("Value: 6.1.16", nil).Times(1),
+
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(hotplug, "", nil).Times(1),
+			ManageMock.EXPECT().runOutErr("controlvm", VM, "plugcpu", "3").Return("", "VBoxManage: error: Permission denied", cpuErr).Times(1),
+		)
+		if err := ScaleMachine(VM, 2, 2048); err != nil {
+			t.Fatal(err)
+		}
+		if err := ScaleMachine(VM, 2, 512); err != nil {
+			t.Fatal(err)
+		}
+		err := ScaleMachine(VM, 2, 2048)
+		if !errors.Is(err, ErrMachineRunning) || !strings.Contains(err.Error(), "CPU hotplug is off") || !strings.Contains(err.Error(), "2048MB") {
+			t.Fatalf("expected both changes to be reported as needing a stop, got %v", err)
+		}
+		if err := ScaleMachine(VM, 4, 0); err != cpuErr {
+			t.Fatalf("expected the plugcpu error, got %v", err)
+		}
+	}
+
+	Teardown()
+}
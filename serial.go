@@ -0,0 +1,102 @@
+package virtualbox
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+)
+
+// serialDefaults are the I/O base and IRQ of the COM1-COM4 ports, used when a
+// serial port is switched on.
+var serialDefaults = [...][2]string{{"0x3F8", "4"}, {"0x2F8", "3"}, {"0x3E8", "4"}, {"0x2E8", "3"}}
+
+func checkSerialPort(n int) error {
+	if n < 1 || n > len(serialDefaults) {
+		return fmt.Errorf("%w: invalid serial port %d, must be in 1--%d", ErrInvalidArgument, n, len(serialDefaults))
+	}
+	return nil
+}
+
+// SerialOption customizes SetSerialTCPServer.
+type SerialOption func(*serialConfig)
+
+type serialConfig struct {
+	checkFree bool
+}
+
+// SerialCheckPortFree fails SetSerialTCPServer when the TCP port is already in
+// use on the host, rather than letting the machine fail to start.
+func SerialCheckPortFree() SerialOption {
+	return func(cfg *serialConfig) {
+		cfg.checkFree = true
+	}
+}
+
+// SetSerialTCPServer has the n-th serial port (starting at 1) of the machine
+// listen on the TCP port of the host, e.g. to follow the boot output of a
+// headless machine with telnet or nc. The serial port is switched on if
+// needed, while the machine is stopped; a running machine can only have the
+// mode of a serial port which is on changed.
+func SetSerialTCPServer(vm string, n, tcpPort int, opts ...SerialOption) error {
+	if err := checkSerialPort(n); err != nil {
+		return err
+	}
+	if tcpPort < 1 || tcpPort > 65535 {
+		return fmt.Errorf("%w: invalid TCP port %d", ErrInvalidArgument, tcpPort)
+	}
+	var cfg serialConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.checkFree {
+		l, err := net.Listen("tcp", net.JoinHostPort("", strconv.Itoa(tcpPort)))
+		if err != nil {
+			return fmt.Errorf("TCP port %d of the host is not available: %w", tcpPort, err)
+		}
+		_ = l.Close()
+	}
+	return setUARTMode(vm, n, "tcpserver", strconv.Itoa(tcpPort))
+}
+
+// SetSerialTCPClient connects the n-th serial port (starting at 1) of the
+// machine to the TCP server at address, given as host:port, e.g. a network
+// attached serial console. See SetSerialTCPServer for when it can be done.
+func SetSerialTCPClient(vm string, n int, address string) error {
+	if err := checkSerialPort(n); err != nil {
+		return err
+	}
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: invalid address '%s': %v", ErrInvalidArgument, address, err)
+	}
+	if p, err := strconv.Atoi(port); host == "" || err != nil || p < 1 || p > 65535 {
+		return fmt.Errorf("%w: invalid address '%s', expected host:port", ErrInvalidArgument, address)
+	}
+	return setUARTMode(vm, n, "tcpclient", address)
+}
+
+// setUARTMode sets the mode of the n-th serial port of the machine, switching
+// the port on when the machine is stopped.
+func setUARTMode(vm string, n int, mode ...string) error {
+	props, err := machineProps(vm)
+	if err != nil {
+		return err
+	}
+	uart := fmt.Sprintf("uart%d", n)
+	off := props[uart] == "off" || props[uart] == ""
+	switch MachineState(props["VMState"]) {
+	case Running, Paused:
+		if !off {
+			return Manage().run(append([]string{"controlvm", vm, fmt.Sprintf("changeuartmode%d", n)}, mode...)...)
+		}
+	}
+	if err := assertMutable(vm); err != nil {
+		return err
+	}
+	args := []string{"modifyvm", vm}
+	if off {
+		args = append(args, "--"+uart, serialDefaults[n-1][0], serialDefaults[n-1][1])
+	}
+	args = append(args, fmt.Sprintf("--uartmode%d", n))
+	return Manage().run(append(args, mode...)...)
+}
@@ -0,0 +1,56 @@
+package virtualbox
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestSetSerialTCP(t *testing.T) {
+	Setup(t)
+
+	for _, addr := range []string{"console", ":2000", "console:0", "console:http"} {
+		if err := SetSerialTCPClient(VM, 1, addr); !errors.Is(err, ErrInvalidArgument) {
+			t.Fatalf("expected ErrInvalidArgument for '%s', got %v", addr, err)
+		}
+	}
+	if err := SetSerialTCPServer(VM, 5, 2000); !errors.Is(err, ErrInvalidArgument) {
+		t.Fatalf("expected ErrInvalidArgument for serial port 5, got %v", err)
+	}
+	l, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	busy := l.Addr().(*net.TCPAddr).Port
+	if err := SetSerialTCPServer(VM, 1, busy, SerialCheckPortFree()); err == nil {
+		t.Fatalf("expected an error for the busy port %d", busy)
+	}
+
+	if ManageMock != nil {
+		vmInfoOut := ReadTestData("vboxmanage-showvminfo-poweroff.out")
+		running := strings.Replace(vmInfoOut, `VMState="poweroff"`, `VMState="running"`, 1)
+		uartOn := strings.Replace(running, `uart2="off"`, `uart2="0x2F8,3"`, 1)
+		gomock.InOrder(
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(2),
+			ManageMock.EXPECT().run("modifyvm", VM, "--uart1", "0x3F8", "4", "--uartmode1", "tcpserver", "2000").Return(nil).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(uartOn, "", nil).Times(1),
+			ManageMock.EXPECT().run("controlvm", VM, "changeuartmode2", "tcpclient", "console.example.com:7001").Return(nil).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(running, "", nil).Times(2),
+		)
+		if err := SetSerialTCPServer(VM, 1, 2000); err != nil {
+			t.Fatal(err)
+		}
+		if err := SetSerialTCPClient(VM, 2, "console.example.com:7001"); err != nil {
+			t.Fatal(err)
+		}
+		if err := SetSerialTCPClient(VM, 1, "console.example.com:7001"); !errors.Is(err, ErrMachineRunning) {
+			t.Fatalf("expected ErrMachineRunning for a serial port which is off, got %v", err)
+		}
+	}
+
+	Teardown()
+}
@@ -0,0 +1,30 @@
+package virtualbox
+
+import "fmt"
+
+// SharedFolder is a host folder shared with the guest.
+type SharedFolder struct {
+	Name      string `json:"name"`
+	HostPath  string `json:"host_path"`
+	Transient bool   `json:"transient"` // lost when the machine is powered off
+}
+
+// parseSharedFolders parses the permanent then the transient shared folders,
+// numbered from 1 in showvminfo.
+func parseSharedFolders(propMap map[string]string) []SharedFolder {
+	var folders []SharedFolder
+	for _, kind := range []string{"Machine", "Transient"} {
+		for i := 1; ; i++ {
+			name, ok := propMap[fmt.Sprintf("SharedFolderName%sMapping%d", kind, i)]
+			if !ok {
+				break
+			}
+			folders = append(folders, SharedFolder{
+				Name:      name,
+				HostPath:  propMap[fmt.Sprintf("SharedFolderPath%sMapping%d", kind, i)],
+				Transient: kind == "Transient",
+			})
+		}
+	}
+	return folders
+}
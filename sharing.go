@@ -0,0 +1,62 @@
+package virtualbox
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrGuestAdditionsRequired holds the error message when the Guest Additions are not running in the guest.
+	ErrGuestAdditionsRequired = errors.New("guest additions are required")
+)
+
+// SharingMode represents the direction of the clipboard or drag'n'drop sharing.
+type SharingMode string
+
+const (
+	// SharingDisabled when nothing is shared.
+	SharingDisabled = SharingMode("disabled")
+	// SharingHostToGuest when the host shares with the guest only.
+	SharingHostToGuest = SharingMode("hosttoguest")
+	// SharingGuestToHost when the guest shares with the host only.
+	SharingGuestToHost = SharingMode("guesttohost")
+	// SharingBidirectional when both sides share with each other.
+	SharingBidirectional = SharingMode("bidirectional")
+)
+
+// SetClipboardMode sets the shared clipboard mode of the machine, live if it
+// is running, or in its settings otherwise.
+func SetClipboardMode(vm string, mode SharingMode) error {
+	return setSharingMode(vm, mode, []string{"clipboard", "mode"}, "--clipboard-mode")
+}
+
+// SetDnDMode sets the drag'n'drop mode of the machine, live if it is running,
+// or in its settings otherwise.
+func SetDnDMode(vm string, mode SharingMode) error {
+	return setSharingMode(vm, mode, []string{"draganddrop"}, "--draganddrop")
+}
+
+func setSharingMode(vm string, mode SharingMode, control []string, modify string) error {
+	switch mode {
+	case SharingDisabled, SharingHostToGuest, SharingGuestToHost, SharingBidirectional:
+	default:
+		return fmt.Errorf("invalid sharing mode: '%s'", mode)
+	}
+
+	m, err := GetMachine(vm)
+	if err != nil {
+		return err
+	}
+	if m.State != Running && m.State != Paused {
+		return Manage().run("modifyvm", vm, modify, string(mode))
+	}
+
+	// Sharing is implemented by the Guest Additions, which report their version once up.
+	if mode != SharingDisabled {
+		if _, err := GetGuestProperty(vm, "/VirtualBox/GuestAdd/Version"); err != nil {
+			return fmt.Errorf("cannot share with '%s': %w", vm, ErrGuestAdditionsRequired)
+		}
+	}
+	args := append([]string{"controlvm", vm}, control...)
+	return Manage().run(append(args, string(mode))...)
+}
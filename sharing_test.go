@@ -0,0 +1,63 @@
+package virtualbox
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestSetClipboardModeStopped(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		vmInfoOut := ReadTestData("vboxmanage-showvminfo-1.out")
+		gomock.InOrder(
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+			ManageMock.EXPECT().run("modifyvm", VM, "--clipboard-mode", "bidirectional").Return(nil).Times(1),
+		)
+		if err := SetClipboardMode(VM, SharingBidirectional); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	Teardown()
+}
+
+func TestSetClipboardModeRunning(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		vmInfoOut := strings.Replace(ReadTestData("vboxmanage-showvminfo-1.out"), `VMState="saved"`, `VMState="running"`, 1)
+		gomock.InOrder(
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+			ManageMock.EXPECT().isGuest().Return(false),
+			ManageMock.EXPECT().runOut("guestproperty", "get", VM, "/VirtualBox/GuestAdd/Version").Return("Value: 6.1.30", nil).Times(1),
+			ManageMock.EXPECT().run("controlvm", VM, "clipboard", "mode", "hosttoguest").Return(nil).Times(1),
+		)
+		if err := SetClipboardMode(VM, SharingHostToGuest); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	Teardown()
+}
+
+func TestSetDnDModeNoGuestAdditions(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		vmInfoOut := strings.Replace(ReadTestData("vboxmanage-showvminfo-1.out"), `VMState="saved"`, `VMState="running"`, 1)
+		gomock.InOrder(
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+			ManageMock.EXPECT().isGuest().Return(false),
+			ManageMock.EXPECT().runOut("guestproperty", "get", VM, "/VirtualBox/GuestAdd/Version").Return("No value set!", nil).Times(1),
+		)
+		if err := SetDnDMode(VM, SharingBidirectional); !errors.Is(err, ErrGuestAdditionsRequired) {
+			t.Fatalf("expected ErrGuestAdditionsRequired, got %v", err)
+		}
+	}
+
+	Teardown()
+}
@@ -0,0 +1,410 @@
+package virtualbox
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	reSnapshotNotFound   = regexp.MustCompile(`Could not find a snapshot`)
+	reSnapshotKey        = regexp.MustCompile(`^Snapshot(Name|UUID|Description)((?:-\d+)*)$`)
+	reSnapshotInfoLine   = regexp.MustCompile(`^([^:]+?):?\s{2,}(.*)$`)
+	reSnapshotStateSince = regexp.MustCompile(`\(since (.+)\)`)
+)
+
+var (
+	// ErrSnapshotNotExist holds the error message when the snapshot does not exist.
+	ErrSnapshotNotExist = errors.New("snapshot does not exist")
+)
+
+// EditSnapshot changes the name and/or description of the given snapshot.
+// An empty newName or newDescription leaves the corresponding value unchanged.
+func EditSnapshot(vm, snapshot, newName, newDescription string) error {
+	args := []string{"snapshot", vm, "edit", snapshot}
+	if newName != "" {
+		args = append(args, "--name", newName)
+	}
+	if newDescription != "" {
+		args = append(args, "--description", newDescription)
+	}
+	if len(args) == 4 {
+		return nil // nothing to change
+	}
+	_, stderr, err := Manage().runOutErr(args...)
+	if err != nil {
+		if reSnapshotNotFound.MatchString(stderr) {
+			return ErrSnapshotNotExist
+		}
+		return err
+	}
+	return nil
+}
+
+// Snapshot holds the summary of a machine snapshot, as listed by VBoxManage.
+type Snapshot struct {
+	Name        string `json:"name"`
+	UUID        string `json:"uuid"`
+	Description string `json:"description"`
+	Parent      string `json:"parent"` // UUID of the parent snapshot, empty for the root snapshot
+	Current     bool   `json:"current"`
+}
+
+// SnapshotDetail holds the configuration captured by a snapshot.
+type SnapshotDetail struct {
+	Snapshot
+	TimeStamp time.Time `json:"timestamp"` // when the snapshot was taken
+	OSType    string    `json:"os_type"`
+	CPUs      uint      `json:"cpus"`
+	Memory    uint      `json:"memory"` // main memory (in MB)
+	VRAM      uint      `json:"vram"`   // video memory (in MB)
+}
+
+// ListSnapshots lists the snapshots of the given machine. Parents are always
+// listed before their children.
+func ListSnapshots(vm string) ([]*Snapshot, error) {
+	stdout, stderr, err := Manage().runOutErr("snapshot", vm, "list", "--machinereadable")
+	if err != nil {
+		if reMachineNotFound.FindString(stderr) != "" {
+			return nil, ErrMachineNotExist
+		}
+		// A machine without snapshots makes VBoxManage fail as well.
+		if strings.Contains(stdout+stderr, "does not have any snapshots") {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var snapshots []*Snapshot
+	nodes := map[string]*Snapshot{} // keyed by the node suffix, e.g. "-1-2"
+	current := ""
+	s := bufio.NewScanner(strings.NewReader(stdout))
+	for s.Scan() {
+		res := reVMInfoLine.FindStringSubmatch(s.Text())
+		if res == nil {
+			continue
+		}
+		key := res[1]
+		if key == "" {
+			key = res[2]
+		}
+		val := res[3]
+		if val == "" {
+			val = res[4]
+		}
+		if key == "CurrentSnapshotUUID" {
+			current = val
+			continue
+		}
+		node := reSnapshotKey.FindStringSubmatch(key)
+		if node == nil {
+			continue
+		}
+		snap, ok := nodes[node[2]]
+		if !ok {
+			snap = &Snapshot{}
+			if i := strings.LastIndex(node[2], "-"); i >= 0 {
+				if parent, ok := nodes[node[2][:i]]; ok {
+					snap.Parent = parent.UUID
+				}
+			}
+			nodes[node[2]] = snap
+			snapshots = append(snapshots, snap)
+		}
+		switch node[1] {
+		case "Name":
+			snap.Name = val
+		case "UUID":
+			snap.UUID = val
+		case "Description":
+			snap.Description = val
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	for _, snap := range snapshots {
+		snap.Current = snap.UUID == current
+	}
+	return snapshots, nil
+}
+
+// GetSnapshot finds a snapshot of the given machine by its name or UUID and
+// retrieves the configuration it captured.
+func GetSnapshot(vm, name string) (*SnapshotDetail, error) {
+	snapshots, err := ListSnapshots(vm)
+	if err != nil {
+		return nil, err
+	}
+	var snap *Snapshot
+	for _, s := range snapshots {
+		if s.Name == name || s.UUID == name {
+			snap = s
+			break
+		}
+	}
+	if snap == nil {
+		return nil, ErrSnapshotNotExist
+	}
+
+	stdout, stderr, err := Manage().runOutErr("snapshot", vm, "showvminfo", snap.UUID)
+	if err != nil {
+		if reSnapshotNotFound.MatchString(stderr) {
+			return nil, ErrSnapshotNotExist
+		}
+		return nil, err
+	}
+
+	d := &SnapshotDetail{Snapshot: *snap}
+	s := bufio.NewScanner(strings.NewReader(stdout))
+	for s.Scan() {
+		res := reSnapshotInfoLine.FindStringSubmatch(s.Text())
+		if res == nil {
+			continue
+		}
+		switch key, val := res[1], res[2]; key {
+		case "Guest OS":
+			d.OSType = val
+		case "Number of CPUs":
+			n, err := strconv.ParseUint(val, 10, 32)
+			if err != nil {
+				return nil, err
+			}
+			d.CPUs = uint(n)
+		case "Memory size", "VRAM size":
+			n, err := strconv.ParseUint(strings.TrimSuffix(val, "MB"), 10, 32)
+			if err != nil {
+				return nil, err
+			}
+			if key == "Memory size" {
+				d.Memory = uint(n)
+			} else {
+				d.VRAM = uint(n)
+			}
+		case "State":
+			if m := reSnapshotStateSince.FindStringSubmatch(val); m != nil {
+				ts, err := time.Parse("2006-01-02T15:04:05", m[1])
+				if err != nil {
+					return nil, err
+				}
+				d.TimeStamp = ts
+			}
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// SetSnapshotFolder sets the directory the machine keeps its snapshots in,
+// e.g. on a data volume. The directory must exist and the machine must not be
+// running.
+func SetSnapshotFolder(vm, dir string) error {
+	if err := checkWritableDir(dir); err != nil {
+		return err
+	}
+	if err := assertMutable(vm); err != nil {
+		return err
+	}
+	return Manage().run("modifyvm", vm, "--snapshotfolder", dir)
+}
+
+// TakeSnapshot takes a snapshot of the machine with the given name and
+// optional description. A running machine keeps running while its state is
+// saved.
+func TakeSnapshot(vm, name, description string) error {
+	args := []string{"snapshot", vm, "take", name}
+	if description != "" {
+		args = append(args, "--description", description)
+	}
+	return Manage().run(append(args, "--live")...)
+}
+
+// DeleteSnapshot deletes the given snapshot of the machine. Its differencing
+// disks are merged into its children.
+func DeleteSnapshot(vm, name string) error {
+	_, stderr, err := Manage().runOutErr("snapshot", vm, "delete", name)
+	if err != nil {
+		if reSnapshotNotFound.MatchString(stderr) {
+			return ErrSnapshotNotExist
+		}
+		return err
+	}
+	return nil
+}
+
+// DeleteSnapshotTree deletes the given snapshot along with all its
+// descendants. Children are always deleted before their parent so that no
+// differencing disk is left orphaned. When a deletion fails, the returned
+// error names the snapshots which were already deleted.
+func DeleteSnapshotTree(vm, name string) error {
+	snapshots, err := ListSnapshots(vm)
+	if err != nil {
+		return err
+	}
+
+	// Snapshots are listed parents first, so collecting the subtree in
+	// listing order and walking it backwards yields a bottom-up order.
+	inTree := map[string]bool{}
+	var tree []*Snapshot
+	for _, s := range snapshots {
+		if (len(tree) == 0 && (s.Name == name || s.UUID == name)) || inTree[s.Parent] {
+			inTree[s.UUID] = true
+			tree = append(tree, s)
+		}
+	}
+	if len(tree) == 0 {
+		return ErrSnapshotNotExist
+	}
+
+	deleted := []string{}
+	for i := len(tree) - 1; i >= 0; i-- {
+		if err := DeleteSnapshot(vm, tree[i].UUID); err != nil {
+			return fmt.Errorf("failed deleting snapshot '%s' (deleted: %v): %w", tree[i].Name, deleted, err)
+		}
+		deleted = append(deleted, tree[i].Name)
+	}
+	return nil
+}
+
+// RestoreSnapshot restores the given snapshot of the machine, which must not be running.
+func RestoreSnapshot(vm, name string) error {
+	_, stderr, err := Manage().runOutErr("snapshot", vm, "restore", name)
+	if err != nil {
+		if reSnapshotNotFound.MatchString(stderr) {
+			return ErrSnapshotNotExist
+		}
+		return err
+	}
+	return nil
+}
+
+// GracefulStopTimeout bounds how long ResetToSnapshot waits for the guest to
+// honor the ACPI power button before powering the machine off.
+var GracefulStopTimeout = 1 * time.Minute
+
+// ResetToSnapshot brings the machine back to the given snapshot. A running
+// machine is first shut down gracefully, and powered off if it does not stop
+// within GracefulStopTimeout; a saved state is discarded. It is safe to call
+// repeatedly.
+func ResetToSnapshot(vm, snapshot string) error {
+	m, err := GetMachine(vm)
+	if err != nil {
+		return err
+	}
+
+	switch m.State {
+	case Running:
+		if err := Manage().run("controlvm", vm, "acpipowerbutton"); err != nil {
+			return err
+		}
+		if err := WaitUntilState(vm, Poweroff, GracefulStopTimeout); err != nil {
+			if err != ErrStateTimeout {
+				return err
+			}
+			if err := Manage().run("controlvm", vm, "poweroff"); err != nil {
+				return err
+			}
+		}
+	case Paused:
+		// A paused guest cannot react to the ACPI power button.
+		if err := Manage().run("controlvm", vm, "poweroff"); err != nil {
+			return err
+		}
+	case Saved:
+		if err := Manage().run("discardstate", vm); err != nil {
+			return err
+		}
+	}
+	if m.State == Running || m.State == Paused {
+		if err := WaitUntilState(vm, Poweroff, GracefulStopTimeout); err != nil {
+			return err
+		}
+	}
+
+	return RestoreSnapshot(vm, snapshot)
+}
+
+// RollbackTimeout bounds how long WithSnapshotRollback waits for the machine
+// to stop or start again while rolling back.
+var RollbackTimeout = 1 * time.Minute
+
+// WithSnapshotRollback runs fn between a temporary snapshot of the machine and
+// its deletion, for risky multi-step changes to the guest. When fn fails or
+// panics, the machine is powered off, brought back to the snapshot and, if it
+// was running or paused before, started again (and paused) before the
+// snapshot is deleted. The error of fn is returned, along with the rollback
+// one if any; a panic is propagated after the rollback.
+func WithSnapshotRollback(vm string, fn func() error) error {
+	m, err := GetMachine(vm)
+	if err != nil {
+		return err
+	}
+	state := m.State
+	name := fmt.Sprintf("go-virtualbox-rollback-%d", time.Now().UnixNano())
+	if err := TakeSnapshot(vm, name, "Temporary snapshot of WithSnapshotRollback"); err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			_ = rollbackToSnapshot(vm, name, state)
+			panic(p)
+		}
+	}()
+	if err := fn(); err != nil {
+		if rerr := rollbackToSnapshot(vm, name, state); rerr != nil {
+			return fmt.Errorf("%w (rolling back to snapshot '%s' failed: %v)", err, name, rerr)
+		}
+		return err
+	}
+	return DeleteSnapshot(vm, name)
+}
+
+// rollbackToSnapshot restores the machine to the snapshot, deletes it and
+// brings the machine back to its state from before the snapshot.
+func rollbackToSnapshot(vm, snapshot string, state MachineState) error {
+	m, err := GetMachine(vm)
+	if err != nil {
+		return err
+	}
+	switch m.State {
+	case Running, Paused:
+		// The guest state is about to be thrown away, no need to shut it down.
+		if err := Manage().run("controlvm", vm, "poweroff"); err != nil {
+			return err
+		}
+		if err := WaitUntilState(vm, Poweroff, RollbackTimeout); err != nil {
+			return err
+		}
+	case Saved:
+		if err := Manage().run("discardstate", vm); err != nil {
+			return err
+		}
+	}
+	if err := RestoreSnapshot(vm, snapshot); err != nil {
+		return err
+	}
+	if err := DeleteSnapshot(vm, snapshot); err != nil {
+		return err
+	}
+	if state != Running && state != Paused {
+		return nil
+	}
+	// The snapshot of a running machine holds its saved state, which startvm resumes.
+	if err := Manage().run("startvm", vm, "--type", "headless"); err != nil {
+		return err
+	}
+	if err := WaitUntilState(vm, Running, RollbackTimeout); err != nil {
+		return err
+	}
+	if state == Paused {
+		return Manage().run("controlvm", vm, "pause")
+	}
+	return nil
+}
@@ -0,0 +1,232 @@
+package virtualbox
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestEditSnapshot(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		ManageMock.EXPECT().runOutErr("snapshot", VM, "edit", "2021-12-30T10:00:00Z", "--name", "clean install").Return("", "", nil).Times(1)
+	}
+	if err := EditSnapshot(VM, "2021-12-30T10:00:00Z", "clean install", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if ManageMock != nil {
+		stderr := "VBoxManage: error: Could not find a snapshot named 'missing'\n"
+		ManageMock.EXPECT().runOutErr("snapshot", VM, "edit", "missing", "--description", "foo").Return("", stderr, errors.New("exit status 1")).Times(1)
+		if err := EditSnapshot(VM, "missing", "", "foo"); err != ErrSnapshotNotExist {
+			t.Fatalf("expected ErrSnapshotNotExist, got %v", err)
+		}
+	}
+
+	Teardown()
+}
+
+func TestListSnapshots(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		out := ReadTestData("vboxmanage-snapshot-list-1.out")
+		ManageMock.EXPECT().runOutErr("snapshot", VM, "list", "--machinereadable").Return(out, "", nil).Times(1)
+	}
+	snapshots, err := ListSnapshots(VM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range snapshots {
+		t.Logf("%+v", s)
+	}
+	if ManageMock != nil {
+		if len(snapshots) != 4 {
+			t.Fatalf("expected 4 snapshots, got %d", len(snapshots))
+		}
+		if snapshots[2].Name != "tests-passed" || snapshots[2].Parent != snapshots[1].UUID || !snapshots[2].Current {
+			t.Fatalf("unexpected snapshot: %+v", snapshots[2])
+		}
+		if snapshots[3].Parent != snapshots[0].UUID {
+			t.Fatalf("unexpected parent for %+v", snapshots[3])
+		}
+	}
+
+	Teardown()
+}
+
+func TestGetSnapshot(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		listOut := ReadTestData("vboxmanage-snapshot-list-1.out")
+		infoOut := ReadTestData("vboxmanage-snapshot-showvminfo-1.out")
+		gomock.InOrder(
+			ManageMock.EXPECT().runOutErr("snapshot", VM, "list", "--machinereadable").Return(listOut, "", nil).Times(1),
+			ManageMock.EXPECT().runOutErr("snapshot", VM, "showvminfo", "3b8e2f8d-7c2a-4b3f-8d3e-1f1c0a9b2e02").Return(infoOut, "", nil).Times(1),
+		)
+	}
+	d, err := GetSnapshot(VM, "provisioned")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("%+v", d)
+	if ManageMock != nil {
+		if d.CPUs != 2 || d.Memory != 2048 || d.VRAM != 16 || d.Current {
+			t.Fatalf("unexpected snapshot detail: %+v", d)
+		}
+		if d.TimeStamp.Format("2006-01-02 15:04:05") != "2021-12-30 10:15:42" {
+			t.Fatalf("unexpected timestamp: %v", d.TimeStamp)
+		}
+	}
+
+	Teardown()
+}
+
+func TestDeleteSnapshotTree(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		listOut := ReadTestData("vboxmanage-snapshot-list-1.out")
+		gomock.InOrder(
+			ManageMock.EXPECT().runOutErr("snapshot", VM, "list", "--machinereadable").Return(listOut, "", nil).Times(1),
+			ManageMock.EXPECT().runOutErr("snapshot", VM, "delete", "5e7f8a9b-0c1d-4e2f-a3b4-c5d6e7f8a904").Return("", "", nil).Times(1),
+			ManageMock.EXPECT().runOutErr("snapshot", VM, "delete", "9a4d6e5f-2b1c-4a8d-b7e6-3c2d1e0f4a03").Return("", "", nil).Times(1),
+			ManageMock.EXPECT().runOutErr("snapshot", VM, "delete", "3b8e2f8d-7c2a-4b3f-8d3e-1f1c0a9b2e02").Return("", "", errors.New("exit status 1")).Times(1),
+		)
+		err := DeleteSnapshotTree(VM, "base")
+		if err == nil {
+			t.Fatal("expected an error")
+		}
+		t.Log(err)
+		if !strings.Contains(err.Error(), "[experiment tests-passed]") {
+			t.Fatalf("expected the deleted snapshots to be reported, got %v", err)
+		}
+	}
+
+	Teardown()
+}
+
+func TestResetToSnapshot(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		vmInfoOut := ReadTestData("vboxmanage-showvminfo-1.out") // saved machine
+		gomock.InOrder(
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+			ManageMock.EXPECT().run("discardstate", VM).Return(nil).Times(1),
+			ManageMock.EXPECT().runOutErr("snapshot", VM, "restore", "base").Return("", "", nil).Times(1),
+		)
+	}
+	if err := ResetToSnapshot(VM, "base"); err != nil {
+		t.Fatal(err)
+	}
+
+	Teardown()
+}
+
+func TestTakeSnapshot(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		ManageMock.EXPECT().run("snapshot", VM, "take", "clean install", "--description", "fresh OS", "--live").Return(nil).Times(1)
+		if err := TakeSnapshot(VM, "clean install", "fresh OS"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	Teardown()
+}
+
+func TestSetSnapshotFolder(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		dir, err := ioutil.TempDir("", "go-virtualbox-test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+		vmInfoOut := ReadTestData("vboxmanage-showvminfo-poweroff.out")
+		gomock.InOrder(
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+			ManageMock.EXPECT().run("modifyvm", VM, "--snapshotfolder", dir).Return(nil).Times(1),
+		)
+		m, err := GetMachine(VM)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m.SnapshotFolder != "/Users/fix/VirtualBox VMs/go-virtualbox/Snapshots" {
+			t.Fatalf("unexpected snapshot folder: '%s'", m.SnapshotFolder)
+		}
+		if err := SetSnapshotFolder(VM, dir); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := SetSnapshotFolder(VM, filepath.Join("does", "not", "exist")); err == nil {
+		t.Fatal("expected an error for a missing directory")
+	}
+
+	Teardown()
+}
+
+func TestWithSnapshotRollback(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		uuid := "37f5d336-bf07-48dd-947c-37e6a56420a7" // WaitUntilState polls by UUID
+		saved := ReadTestData("vboxmanage-showvminfo-1.out")
+		running := strings.Replace(saved, `VMState="saved"`, `VMState="running"`, 1)
+		poweroff := strings.Replace(saved, `VMState="saved"`, `VMState="poweroff"`, 1)
+		take := func() *gomock.Call {
+			return ManageMock.EXPECT().run("snapshot", VM, "take", gomock.Any(), "--description", gomock.Any(), "--live").Return(nil).Times(1)
+		}
+		gomock.InOrder(
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(saved, "", nil).Times(1),
+			take(),
+			ManageMock.EXPECT().runOutErr("snapshot", VM, "delete", gomock.Any()).Return("", "", nil).Times(1),
+
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(running, "", nil).Times(1),
+			take(),
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(running, "", nil).Times(1),
+			ManageMock.EXPECT().run("controlvm", VM, "poweroff").Return(nil).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(running, "", nil).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", uuid, "--machinereadable").Return(poweroff, "", nil).Times(1),
+			ManageMock.EXPECT().runOutErr("snapshot", VM, "restore", gomock.Any()).Return("", "", nil).Times(1),
+			ManageMock.EXPECT().runOutErr("snapshot", VM, "delete", gomock.Any()).Return("", "", nil).Times(1),
+			ManageMock.EXPECT().run("startvm", VM, "--type", "headless").Return(nil).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(saved, "", nil).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", uuid, "--machinereadable").Return(running, "", nil).Times(1),
+
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(poweroff, "", nil).Times(1),
+			take(),
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(poweroff, "", nil).Times(1),
+			ManageMock.EXPECT().runOutErr("snapshot", VM, "restore", gomock.Any()).Return("", "", nil).Times(1),
+			ManageMock.EXPECT().runOutErr("snapshot", VM, "delete", gomock.Any()).Return("", "", nil).Times(1),
+		)
+		if err := WithSnapshotRollback(VM, func() error { return nil }); err != nil {
+			t.Fatal(err)
+		}
+		failed := errors.New("provisioning failed")
+		if err := WithSnapshotRollback(VM, func() error { return failed }); err != failed {
+			t.Fatalf("expected the error of fn, got %v", err)
+		}
+		func() {
+			defer func() {
+				if p := recover(); p != "boom" {
+					t.Fatalf("expected the panic of fn to be propagated, got %v", p)
+				}
+			}()
+			_ = WithSnapshotRollback(VM, func() error { panic("boom") })
+		}()
+	}
+
+	Teardown()
+}
@@ -1,7 +1,14 @@
 package virtualbox
 
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
 // StorageController represents a virtualized storage controller.
 type StorageController struct {
+	Name        string // used by ListStorageControllers and AddStorageController, not by AddStorageCtl
 	SysBus      SystemBus
 	Ports       uint // SATA port count 1--30
 	Chipset     StorageControllerChipset
@@ -83,3 +90,192 @@ const (
 func CloneHD(input, output string) error {
 	return Manage().run("clonehd", input, output)
 }
+
+// DiskAttachment describes a medium attached to a storage controller of a machine.
+type DiskAttachment struct {
+	Controller string    `json:"controller"`
+	Port       uint      `json:"port"`
+	Device     uint      `json:"device"`
+	DriveType  DriveType `json:"drive_type"`
+	Medium     string    `json:"medium"` // file path, "emptydrive" or host:<drive>
+	UUID       string    `json:"uuid"`   // UUID of the medium, empty for an empty drive
+}
+
+// ListAttachments lists the media attached to the storage controllers of the machine.
+func ListAttachments(vm string) ([]DiskAttachment, error) {
+	propMap, err := machineProps(vm)
+	if err != nil {
+		return nil, err
+	}
+	return parseAttachments(propMap)
+}
+
+func parseAttachments(propMap map[string]string) ([]DiskAttachment, error) {
+	attachments := []DiskAttachment{}
+	for i := 0; ; i++ {
+		ctl, ok := propMap[fmt.Sprintf("storagecontrollername%d", i)]
+		if !ok {
+			break
+		}
+		ports, err := strconv.ParseUint(propMap[fmt.Sprintf("storagecontrollerportcount%d", i)], 10, 32)
+		if err != nil {
+			return nil, err
+		}
+		floppy := propMap[fmt.Sprintf("storagecontrollertype%d", i)] == string(CtrlI82078)
+		for port := uint(0); port < uint(ports); port++ {
+			for device := uint(0); device < 2; device++ { // only IDE has a second device per port
+				medium, ok := propMap[fmt.Sprintf("%s-%d-%d", ctl, port, device)]
+				if !ok || medium == "none" {
+					continue
+				}
+				a := DiskAttachment{
+					Controller: ctl,
+					Port:       port,
+					Device:     device,
+					DriveType:  DriveHDD,
+					Medium:     medium,
+					UUID:       propMap[fmt.Sprintf("%s-ImageUUID-%d-%d", ctl, port, device)],
+				}
+				if _, ok := propMap[fmt.Sprintf("%s-IsEjected-%d-%d", ctl, port, device)]; ok || medium == "emptydrive" {
+					a.DriveType = DriveDVD
+				} else if floppy {
+					a.DriveType = DriveFDD
+				}
+				attachments = append(attachments, a)
+			}
+		}
+	}
+	return attachments, nil
+}
+
+// storageControllerExists tells whether the machine has a storage controller
+// with the given name.
+func storageControllerExists(propMap map[string]string, name string) bool {
+	for i := 0; ; i++ {
+		ctl, ok := propMap[fmt.Sprintf("storagecontrollername%d", i)]
+		if !ok {
+			return false
+		}
+		if ctl == name {
+			return true
+		}
+	}
+}
+
+// RemoveStorageController removes the named storage controller of the machine,
+// which must not be running. It fails, listing the attached media, unless they
+// have all been detached first.
+func RemoveStorageController(vm, name string) error {
+	if err := assertMutable(vm); err != nil {
+		return err
+	}
+	propMap, err := machineProps(vm)
+	if err != nil {
+		return err
+	}
+	if !storageControllerExists(propMap, name) {
+		return fmt.Errorf("%w: '%s' has no storage controller '%s'", ErrInvalidArgument, vm, name)
+	}
+	attachments, err := parseAttachments(propMap)
+	if err != nil {
+		return err
+	}
+	var attached []string
+	for _, a := range attachments {
+		if a.Controller == name {
+			attached = append(attached, fmt.Sprintf("%s at port %d device %d", a.Medium, a.Port, a.Device))
+		}
+	}
+	if len(attached) > 0 {
+		return fmt.Errorf("storage controller '%s' of '%s' has media attached: %s", name, vm, strings.Join(attached, ", "))
+	}
+	return Manage().run("storagectl", vm, "--name", name, "--remove")
+}
+
+// RenameStorageController renames the named storage controller of the machine,
+// which must not be running.
+func RenameStorageController(vm, oldName, newName string) error {
+	if newName == "" {
+		return fmt.Errorf("%w: storage controller name is empty", ErrInvalidArgument)
+	}
+	if err := assertMutable(vm); err != nil {
+		return err
+	}
+	propMap, err := machineProps(vm)
+	if err != nil {
+		return err
+	}
+	if !storageControllerExists(propMap, oldName) {
+		return fmt.Errorf("%w: '%s' has no storage controller '%s'", ErrInvalidArgument, vm, oldName)
+	}
+	if storageControllerExists(propMap, newName) {
+		return fmt.Errorf("%w: '%s' already has a storage controller '%s'", ErrInvalidArgument, vm, newName)
+	}
+	return Manage().run("storagectl", vm, "--name", oldName, "--rename", newName)
+}
+
+// storageControllerTypes maps the controller types reported by showvminfo to
+// their chipset and bus.
+var storageControllerTypes = map[string]struct {
+	chipset StorageControllerChipset
+	bus     SystemBus
+}{
+	"lsilogic":    {CtrlLSILogic, SysBusSCSI},
+	"buslogic":    {CtrlBusLogic, SysBusSCSI},
+	"lsilogicsas": {CtrlLSILogicSAS, SysBusSAS},
+	"intelahci":   {CtrlIntelAHCI, SysBusSATA},
+	"piix3":       {CtrlPIIX3, SysBusIDE},
+	"piix4":       {CtrlPIIX4, SysBusIDE},
+	"ich6":        {CtrlICH6, SysBusIDE},
+	"i82078":      {CtrlI82078, SysBusFloppy},
+	"usb":         {CtrlUSB, SysBusUSB},
+	"nvme":        {CtrlNVME, SysBusPCIE},
+	"virtioscsi":  {CtrlVirtIO, SysBusVirtio},
+	"virtio":      {CtrlVirtIO, SysBusVirtio},
+}
+
+// ListStorageControllers lists the storage controllers of the machine, in
+// showvminfo order.
+func ListStorageControllers(vm string) ([]StorageController, error) {
+	propMap, err := machineProps(vm)
+	if err != nil {
+		return nil, err
+	}
+	return parseStorageControllers(propMap)
+}
+
+func parseStorageControllers(propMap map[string]string) ([]StorageController, error) {
+	ctls := []StorageController{}
+	for i := 0; ; i++ {
+		name, ok := propMap[fmt.Sprintf("storagecontrollername%d", i)]
+		if !ok {
+			break
+		}
+		ports, err := strconv.ParseUint(propMap[fmt.Sprintf("storagecontrollerportcount%d", i)], 10, 32)
+		if err != nil {
+			return nil, err
+		}
+		ctl := StorageController{
+			Name:     name,
+			Ports:    uint(ports),
+			Bootable: propMap[fmt.Sprintf("storagecontrollerbootable%d", i)] == "on",
+		}
+		typ := propMap[fmt.Sprintf("storagecontrollertype%d", i)]
+		if t, ok := storageControllerTypes[strings.ToLower(typ)]; ok {
+			ctl.Chipset, ctl.SysBus = t.chipset, t.bus
+		} else {
+			ctl.Chipset = StorageControllerChipset(typ)
+		}
+		ctls = append(ctls, ctl)
+	}
+	return ctls, nil
+}
+
+// AddStorageController adds the storage controller ctl, named ctl.Name, to
+// the machine, see ListStorageControllers.
+func AddStorageController(vm string, ctl StorageController) error {
+	if ctl.Name == "" {
+		return fmt.Errorf("%w: storage controller name is empty", ErrInvalidArgument)
+	}
+	return (&Machine{Name: vm}).AddStorageCtl(ctl.Name, ctl)
+}
@@ -0,0 +1,98 @@
+package virtualbox
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestListAttachments(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		vmInfoOut := ReadTestData("vboxmanage-showvminfo-1.out")
+		gomock.InOrder(
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+		)
+	}
+	attachments, err := ListAttachments(VM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, a := range attachments {
+		t.Logf("%+v", a)
+	}
+	if ManageMock != nil {
+		if len(attachments) != 1 {
+			t.Fatalf("expected 1 attachment, got %d", len(attachments))
+		}
+		a := attachments[0]
+		if a.Controller != "SATA Controller" || a.Port != 0 || a.Device != 0 || a.DriveType != DriveHDD ||
+			a.UUID != "32583b48-693e-45d4-882f-e9196d4f43c6" {
+			t.Fatalf("unexpected attachment: %+v", a)
+		}
+	}
+
+	Teardown()
+}
+
+func TestRemoveStorageController(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		vmInfoOut := ReadTestData("vboxmanage-showvminfo-poweroff.out")
+		ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(8)
+		gomock.InOrder(
+			ManageMock.EXPECT().run("storagectl", VM, "--name", "IDE Controller", "--remove").Return(nil).Times(1),
+			ManageMock.EXPECT().run("storagectl", VM, "--name", "SATA Controller", "--rename", "NVMe Controller").Return(nil).Times(1),
+		)
+		if err := RemoveStorageController(VM, "IDE Controller"); err != nil {
+			t.Fatal(err)
+		}
+		err := RemoveStorageController(VM, "SATA Controller")
+		if err == nil || !strings.Contains(err.Error(), "ubuntu-16.04-amd64-disk001.vmdk at port 0 device 0") {
+			t.Fatalf("expected the attached disk to be listed, got %v", err)
+		}
+		if err := RenameStorageController(VM, "SATA Controller", "NVMe Controller"); err != nil {
+			t.Fatal(err)
+		}
+		if err := RenameStorageController(VM, "SATA Controller", "IDE Controller"); !errors.Is(err, ErrInvalidArgument) {
+			t.Fatalf("expected ErrInvalidArgument for a taken name, got %v", err)
+		}
+	}
+
+	Teardown()
+}
+
+func TestListStorageControllers(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		vmInfoOut := ReadTestData("vboxmanage-showvminfo-poweroff.out")
+		gomock.InOrder(
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(2),
+			ManageMock.EXPECT().run("storagectl", VM, "--name", "SATA Controller", "--add", "sata", "--portcount", "1",
+				"--controller", "IntelAHCI", "--hostiocache", "off", "--bootable", "on").Return(nil).Times(1),
+		)
+		ctls, err := ListStorageControllers(VM)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := []StorageController{
+			{Name: "IDE Controller", SysBus: SysBusIDE, Ports: 2, Chipset: CtrlPIIX4, Bootable: true},
+			{Name: "SATA Controller", SysBus: SysBusSATA, Ports: 1, Chipset: CtrlIntelAHCI, Bootable: true},
+		}
+		if !reflect.DeepEqual(ctls, expected) {
+			t.Fatalf("expected %+v, got %+v", expected, ctls)
+		}
+		// The listed controllers can be added back as is, e.g. to another machine.
+		if err := AddStorageController(VM, ctls[1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	Teardown()
+}
@@ -0,0 +1,151 @@
+package virtualbox
+
+import (
+	"bufio"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SystemProperties holds the host-wide VirtualBox settings and limits.
+type SystemProperties struct {
+	DefaultMachineFolder     string
+	DefaultFrontend          string
+	DefaultGuestAdditionsISO string
+	AutostartDBPath          string
+	ProxyMode                string
+	MinGuestRAM              uint // in MB
+	MaxGuestRAM              uint // in MB
+	MinGuestCPUCount         uint
+	MaxGuestCPUCount         uint
+	MaxNetworkAdapters       uint // with the PIIX3 chipset
+	MaxNetworkAdaptersICH9   uint // with the ICH9 chipset
+	LogHistoryCount          uint
+	HWVirtExclusive          bool
+	Extra                    map[string]string // every other key, as printed by VBoxManage
+}
+
+// GetSystemProperties gets the host-wide VirtualBox settings and limits.
+func GetSystemProperties() (*SystemProperties, error) {
+	out, err := Manage().runOut("list", "systemproperties")
+	if err != nil {
+		return nil, err
+	}
+	p := &SystemProperties{Extra: map[string]string{}}
+	s := bufio.NewScanner(strings.NewReader(out))
+	for s.Scan() {
+		res := reColonLine.FindStringSubmatch(s.Text())
+		if res == nil {
+			continue
+		}
+		key, val := res[1], strings.TrimSpace(res[2])
+		var n *uint
+		switch key {
+		case "Default machine folder":
+			p.DefaultMachineFolder = val
+		case "Default frontend":
+			p.DefaultFrontend = val
+		case "Default Guest Additions ISO":
+			p.DefaultGuestAdditionsISO = val
+		case "Autostart database path":
+			p.AutostartDBPath = val
+		case "Proxy Mode":
+			p.ProxyMode = val
+		case "Exclusive HW virtualization use":
+			p.HWVirtExclusive = (val == "on")
+		case "Minimum guest RAM size":
+			n = &p.MinGuestRAM
+		case "Maximum guest RAM size":
+			n = &p.MaxGuestRAM
+		case "Minimum guest CPU count":
+			n = &p.MinGuestCPUCount
+		case "Maximum guest CPU count":
+			n = &p.MaxGuestCPUCount
+		case "Maximum PIIX3 Network Adapter count":
+			n = &p.MaxNetworkAdapters
+		case "Maximum ICH9 Network Adapter count":
+			n = &p.MaxNetworkAdaptersICH9
+		case "Log history count":
+			n = &p.LogHistoryCount
+		default:
+			p.Extra[key] = val
+		}
+		if f := strings.Fields(val); n != nil && len(f) > 0 {
+			// Numbers may carry a unit, e.g. "2097152 Megabytes".
+			v, err := strconv.ParseUint(f[0], 10, 32)
+			if err != nil {
+				return nil, err
+			}
+			*n = uint(v)
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// SetDefaultMachineFolder changes the folder new machines are created in by
+// default. The folder must exist and be writable.
+func SetDefaultMachineFolder(path string) error {
+	return SetProperty("machinefolder", path)
+}
+
+// folderProperties are the host-wide settings which name a folder. "default"
+// resets them to the VirtualBox default.
+var folderProperties = map[string]bool{
+	"autostartdbpath": true,
+	"machinefolder":   true,
+}
+
+// SetProperty changes the given host-wide VirtualBox setting. The folder
+// settings, such as machinefolder, must name an existing writable folder.
+func SetProperty(name, value string) error {
+	if name == "" {
+		return fmt.Errorf("%w: empty system property name", ErrInvalidArgument)
+	}
+	if folderProperties[name] && value != "default" {
+		if err := checkWritableDir(value); err != nil {
+			return err
+		}
+	}
+	return Manage().run("setproperty", name, value)
+}
+
+// SetLogHistoryCount sets how many rotated machine logs are kept.
+func SetLogHistoryCount(n uint) error {
+	return SetProperty("loghistorycount", strconv.FormatUint(uint64(n), 10))
+}
+
+// SetHWVirtExclusive sets whether VirtualBox claims the hardware
+// virtualization extensions exclusively.
+func SetHWVirtExclusive(on bool) error {
+	return SetProperty("hwvirtexclusive", bool2string(on))
+}
+
+// SetAutostartDBPath sets the path of the machine autostart database.
+func SetAutostartDBPath(path string) error {
+	return SetProperty("autostartdbpath", path)
+}
+
+// ProxyMode represents how VirtualBox reaches the network for its own needs.
+type ProxyMode string
+
+const (
+	// ProxySystem uses the proxy settings of the host.
+	ProxySystem = ProxyMode("system")
+	// ProxyNone connects directly.
+	ProxyNone = ProxyMode("noproxy")
+	// ProxyManual uses the proxy given by the proxyurl property.
+	ProxyManual = ProxyMode("manual")
+)
+
+// SetProxyMode sets how VirtualBox reaches the network, e.g. for update checks.
+func SetProxyMode(mode ProxyMode) error {
+	switch mode {
+	case ProxySystem, ProxyNone, ProxyManual:
+	default:
+		return fmt.Errorf("invalid proxy mode: '%s'", mode)
+	}
+	return SetProperty("proxymode", string(mode))
+}
@@ -0,0 +1,94 @@
+package virtualbox
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestGetSystemProperties(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		listSystemPropertiesOut := ReadTestData("vboxmanage-list-systemproperties-1.out")
+		gomock.InOrder(
+			ManageMock.EXPECT().runOut("list", "systemproperties").Return(listSystemPropertiesOut, nil).Times(1),
+		)
+	}
+	p, err := GetSystemProperties()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("%+v", p)
+	if ManageMock != nil {
+		if p.DefaultMachineFolder != "/Users/fix/VirtualBox VMs" || p.MaxGuestCPUCount != 32 || p.MaxGuestRAM != 2097152 {
+			t.Fatalf("unexpected system properties: %+v", p)
+		}
+		if p.Extra["API version"] != "6_1" {
+			t.Fatalf("unknown keys not exposed: %+v", p.Extra)
+		}
+	}
+
+	Teardown()
+}
+
+func TestSetDefaultMachineFolder(t *testing.T) {
+	Setup(t)
+
+	dir, err := ioutil.TempDir("", "go-virtualbox-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := SetDefaultMachineFolder(filepath.Join(dir, "missing")); !os.IsNotExist(err) {
+		t.Fatalf("expected a missing folder error, got %v", err)
+	}
+	if ManageMock != nil {
+		ManageMock.EXPECT().run("setproperty", "machinefolder", dir).Return(nil).Times(1)
+		if err := SetDefaultMachineFolder(dir); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	Teardown()
+}
+
+func TestSetProperty(t *testing.T) {
+	Setup(t)
+
+	dir, err := ioutil.TempDir("", "go-virtualbox-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := SetProperty("", "on"); !errors.Is(err, ErrInvalidArgument) {
+		t.Fatalf("expected ErrInvalidArgument for an empty name, got %v", err)
+	}
+	if err := SetProperty("autostartdbpath", filepath.Join(dir, "missing")); !os.IsNotExist(err) {
+		t.Fatalf("expected a missing folder error, got %v", err)
+	}
+	if ManageMock != nil {
+		gomock.InOrder(
+			ManageMock.EXPECT().run("setproperty", "vrdeauthlibrary", "VBoxAuthSimple").Return(nil).Times(1),
+			ManageMock.EXPECT().run("setproperty", "autostartdbpath", dir).Return(nil).Times(1),
+			ManageMock.EXPECT().run("setproperty", "machinefolder", "default").Return(nil).Times(1),
+		)
+		if err := SetProperty("vrdeauthlibrary", "VBoxAuthSimple"); err != nil {
+			t.Fatal(err)
+		}
+		if err := SetProperty("autostartdbpath", dir); err != nil {
+			t.Fatal(err)
+		}
+		if err := SetProperty("machinefolder", "default"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	Teardown()
+}
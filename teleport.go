@@ -0,0 +1,125 @@
+package virtualbox
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+)
+
+// teleports holds the cancel functions of the teleports in progress, keyed by
+// machine UUID.
+var teleports struct {
+	sync.Mutex
+	cancels map[string]context.CancelFunc
+}
+
+// TeleportOption customizes SetTeleporter and Teleport.
+type TeleportOption func(*teleportConfig)
+
+type teleportConfig struct {
+	password string
+}
+
+// TeleportPassword protects the teleport with a password: the target machine
+// only accepts a teleport from a source giving the same one. Without it,
+// anyone reaching the port can teleport a machine in. The password is passed
+// to VirtualBox through a private temporary file.
+func TeleportPassword(password string) TeleportOption {
+	return func(cfg *teleportConfig) {
+		cfg.password = password
+	}
+}
+
+func teleportOptions(opts []TeleportOption) teleportConfig {
+	var cfg teleportConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// SetTeleporter makes the machine, which must not be running, wait for an
+// incoming teleport on the given TCP port when started, or not.
+func SetTeleporter(vm string, on bool, port uint16, opts ...TeleportOption) error {
+	if err := assertMutable(vm); err != nil {
+		return err
+	}
+	cfg := teleportOptions(opts)
+	args := []string{"modifyvm", vm, "--teleporter", bool2string(on)}
+	if !on {
+		return Manage().run(args...)
+	}
+	args = append(args, "--teleporterport", strconv.Itoa(int(port)))
+	if cfg.password == "" {
+		return Manage().run(args...)
+	}
+	return withSecretFile(cfg.password, func(path string) error {
+		return Manage().run(append(args, "--teleporterpasswordfile", path)...)
+	})
+}
+
+// Teleport live-migrates the running machine to the target host, where a
+// machine set up with SetTeleporter waits on the given port. The progress, if
+// not nil, is called with the percentage done. The TeleportPassword given to
+// SetTeleporter on the target must be given here too. Cancelling ctx, or
+// calling CancelTeleport, aborts the migration and leaves the machine running
+// here.
+func Teleport(ctx context.Context, vm, host string, port uint16, progress func(percent int), opts ...TeleportOption) error {
+	_, uuid, err := ResolveMachine(vm)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	teleports.Lock()
+	if _, ok := teleports.cancels[uuid]; ok {
+		teleports.Unlock()
+		return fmt.Errorf("a teleport of '%s' is already in progress", vm)
+	}
+	if teleports.cancels == nil {
+		teleports.cancels = map[string]context.CancelFunc{}
+	}
+	teleports.cancels[uuid] = cancel
+	teleports.Unlock()
+	defer func() {
+		teleports.Lock()
+		delete(teleports.cancels, uuid)
+		teleports.Unlock()
+	}()
+
+	cmdOpts := []Option{withContext(ctx)}
+	if progress != nil {
+		cmdOpts = append(cmdOpts, withProgress(progress))
+	}
+	args := []string{"controlvm", uuid, "teleport", "--host", host, "--port", strconv.Itoa(int(port))}
+	if cfg := teleportOptions(opts); cfg.password != "" {
+		err = withSecretFile(cfg.password, func(path string) error {
+			return Manage().setOpts(cmdOpts...).run(append(args, "--passwordfile", path)...)
+		})
+	} else {
+		err = Manage().setOpts(cmdOpts...).run(args...)
+	}
+	if err != nil && ctx.Err() != nil {
+		return fmt.Errorf("teleport of '%s' aborted: %w", vm, ctx.Err())
+	}
+	return err
+}
+
+// CancelTeleport aborts the teleport of the machine started by Teleport,
+// given by name or UUID.
+func CancelTeleport(vm string) error {
+	_, uuid, err := ResolveMachine(vm)
+	if err != nil {
+		return err
+	}
+	teleports.Lock()
+	defer teleports.Unlock()
+	cancel, ok := teleports.cancels[uuid]
+	if !ok {
+		return fmt.Errorf("no teleport of '%s' in progress", vm)
+	}
+	cancel()
+	return nil
+}
@@ -0,0 +1,78 @@
+package virtualbox
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestTeleportCancel(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		vmInfoOut := ReadTestData("vboxmanage-showvminfo-1.out")
+		uuid := "37f5d336-bf07-48dd-947c-37e6a56420a7"
+		gomock.InOrder(
+			ManageMock.EXPECT().runOutErr("showvminfo", uuid, "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+			ManageMock.EXPECT().setOpts(gomock.Any(), gomock.Any()).Return(ManageMock).Times(1),
+			ManageMock.EXPECT().run("controlvm", uuid, "teleport", "--host", "target.example.com", "--port", "6000").
+				DoAndReturn(func(args ...string) error {
+					// Cancelled by name, teleported by UUID.
+					if err := CancelTeleport("go-virtualbox"); err != nil {
+						t.Fatal(err)
+					}
+					return errors.New("signal: interrupt")
+				}).Times(1),
+		)
+		ManageMock.EXPECT().runOutErr("showvminfo", "go-virtualbox", "--machinereadable").Return(vmInfoOut, "", nil).Times(2)
+		err := Teleport(context.Background(), uuid, "target.example.com", 6000, func(int) {})
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected a cancellation error, got %v", err)
+		}
+		if err := CancelTeleport("go-virtualbox"); err == nil {
+			t.Fatal("expected an error once the teleport is over")
+		}
+	}
+
+	Teardown()
+}
+
+func TestSetTeleporter(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		vmInfoOut := ReadTestData("vboxmanage-showvminfo-poweroff.out")
+		var passwordFile string
+		ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(2)
+		gomock.InOrder(
+			ManageMock.EXPECT().run("modifyvm", VM, "--teleporter", "on", "--teleporterport", "6000", "--teleporterpasswordfile", gomock.Any()).
+				DoAndReturn(func(args ...string) error {
+					passwordFile = args[len(args)-1]
+					b, err := ioutil.ReadFile(passwordFile)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if string(b) != "s3cr3t" {
+						t.Fatalf("expected the password in %s, got %q", passwordFile, b)
+					}
+					return nil
+				}).Times(1),
+			ManageMock.EXPECT().run("modifyvm", VM, "--teleporter", "off").Return(nil).Times(1),
+		)
+		if err := SetTeleporter(VM, true, 6000, TeleportPassword("s3cr3t")); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := os.Stat(passwordFile); !os.IsNotExist(err) {
+			t.Fatalf("password file %s was not removed", passwordFile)
+		}
+		if err := SetTeleporter(VM, false, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	Teardown()
+}
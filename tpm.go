@@ -0,0 +1,78 @@
+package virtualbox
+
+import "fmt"
+
+// TPMType represents the emulated Trusted Platform Module of a machine.
+type TPMType string
+
+const (
+	// TPMNone when the machine has no TPM.
+	TPMNone = TPMType("none")
+	// TPM12 when the machine has a TPM 1.2.
+	TPM12 = TPMType("1.2")
+	// TPM20 when the machine has a TPM 2.0, as required by Windows 11.
+	TPM20 = TPMType("2.0")
+	// TPMHost when the host TPM is passed through.
+	TPMHost = TPMType("host")
+	// TPMSWTPM when an external swtpm process emulates the TPM.
+	TPMSWTPM = TPMType("swtpm")
+)
+
+// tpmTypeFromInfo maps the tpm_type showvminfo values, e.g. "v2_0", to a TPMType.
+func tpmTypeFromInfo(val string) TPMType {
+	switch val {
+	case "v1_2":
+		return TPM12
+	case "v2_0":
+		return TPM20
+	}
+	return TPMType(val)
+}
+
+// IOMMUType represents the emulated IOMMU of a machine.
+type IOMMUType string
+
+const (
+	// IOMMUNone when the machine has no IOMMU.
+	IOMMUNone = IOMMUType("none")
+	// IOMMUAutomatic when VirtualBox picks the IOMMU matching the host CPU.
+	IOMMUAutomatic = IOMMUType("automatic")
+	// IOMMUAMD when the machine has an AMD IOMMU.
+	IOMMUAMD = IOMMUType("amd")
+	// IOMMUIntel when the machine has an Intel IOMMU.
+	IOMMUIntel = IOMMUType("intel")
+)
+
+// SetTPM sets the TPM of the machine, which must not be running. It requires
+// VirtualBox 7.0.
+func SetTPM(vm string, typ TPMType) error {
+	switch typ {
+	case TPMNone, TPM12, TPM20, TPMHost, TPMSWTPM:
+	default:
+		return fmt.Errorf("invalid TPM type: '%s'", typ)
+	}
+	if err := requireVersion(7, 0, "TPM"); err != nil {
+		return err
+	}
+	if err := assertMutable(vm); err != nil {
+		return err
+	}
+	return Manage().run("modifyvm", vm, "--tpm-type", string(typ))
+}
+
+// SetIOMMU sets the IOMMU of the machine, which must not be running. It
+// requires VirtualBox 6.1.
+func SetIOMMU(vm string, typ IOMMUType) error {
+	switch typ {
+	case IOMMUNone, IOMMUAutomatic, IOMMUAMD, IOMMUIntel:
+	default:
+		return fmt.Errorf("invalid IOMMU type: '%s'", typ)
+	}
+	if err := requireVersion(6, 1, "IOMMU"); err != nil {
+		return err
+	}
+	if err := assertMutable(vm); err != nil {
+		return err
+	}
+	return Manage().run("modifyvm", vm, "--iommu", string(typ))
+}
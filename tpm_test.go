@@ -0,0 +1,49 @@
+package virtualbox
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestSetTPM(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		vmInfoOut := ReadTestData("vboxmanage-showvminfo-poweroff.out")
+		withTPM := strings.Replace(vmInfoOut, "monitorcount=1", "monitorcount=1\ntpm_type=\"v2_0\"\niommu=\"automatic\"", 1)
+		gomock.InOrder(
+			ManageMock.EXPECT().runOut("--version").Return("7.0.4r154605\n", nil).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+			ManageMock.EXPECT().run("modifyvm", VM, "--tpm-type", "2.0").Return(nil).Times(1),
+			ManageMock.EXPECT().runOut("--version").Return("7.0.4r154605\n", nil).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+			ManageMock.EXPECT().run("modifyvm", VM, "--iommu", "automatic").Return(nil).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(withTPM, "", nil).Times(1),
+			ManageMock.EXPECT().runOut("--version").Return("6.1.30r148432\n", nil).Times(1),
+		)
+		if err := SetTPM(VM, TPM20); err != nil {
+			t.Fatal(err)
+		}
+		if err := SetIOMMU(VM, IOMMUAutomatic); err != nil {
+			t.Fatal(err)
+		}
+		m, err := GetMachine(VM)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m.TPM != TPM20 || m.IOMMU != IOMMUAutomatic {
+			t.Fatalf("unexpected TPM %s, IOMMU %s", m.TPM, m.IOMMU)
+		}
+		if err := SetTPM(VM, TPM20); !errors.Is(err, ErrUnsupportedVersion) {
+			t.Fatalf("expected ErrUnsupportedVersion, got %v", err)
+		}
+	}
+	if err := SetTPM(VM, "3.0"); err == nil {
+		t.Fatal("expected an error for an invalid TPM type")
+	}
+
+	Teardown()
+}
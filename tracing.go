@@ -0,0 +1,27 @@
+package virtualbox
+
+// TracingConfig holds the VM tracing settings of a machine.
+type TracingConfig struct {
+	Enabled       bool
+	Config        string // tracepoints to enable, passed as is; left unchanged if empty
+	AllowVMAccess bool   // let the tracer read the guest memory and registers
+}
+
+// ConfigureTracing applies the tracing settings of the machine, which must not
+// be running.
+func ConfigureTracing(vm string, cfg TracingConfig) error {
+	if err := requireVersion(5, 0, "VM tracing"); err != nil {
+		return err
+	}
+	if err := assertMutable(vm); err != nil {
+		return err
+	}
+	args := []string{"modifyvm", vm,
+		"--tracing-enabled", bool2string(cfg.Enabled),
+		"--tracing-allow-vm-access", bool2string(cfg.AllowVMAccess),
+	}
+	if cfg.Config != "" {
+		args = append(args, "--tracing-config", cfg.Config)
+	}
+	return Manage().run(args...)
+}
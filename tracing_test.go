@@ -0,0 +1,31 @@
+package virtualbox
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestConfigureTracing(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		vmInfoOut := ReadTestData("vboxmanage-showvminfo-poweroff.out")
+		gomock.InOrder(
+			ManageMock.EXPECT().runOut("--version").Return("6.1.30r148432\n", nil).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+			ManageMock.EXPECT().run("modifyvm", VM, "--tracing-enabled", "on", "--tracing-allow-vm-access", "off",
+				"--tracing-config", "all").Return(nil).Times(1),
+			ManageMock.EXPECT().runOut("--version").Return("4.3.40r110317\n", nil).Times(1),
+		)
+		if err := ConfigureTracing(VM, TracingConfig{Enabled: true, Config: "all"}); err != nil {
+			t.Fatal(err)
+		}
+		if err := ConfigureTracing(VM, TracingConfig{Enabled: true}); !errors.Is(err, ErrUnsupportedVersion) {
+			t.Fatalf("expected ErrUnsupportedVersion, got %v", err)
+		}
+	}
+
+	Teardown()
+}
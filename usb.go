@@ -0,0 +1,35 @@
+package virtualbox
+
+import "fmt"
+
+// USBController represents the emulated USB host controller of a machine.
+type USBController string
+
+const (
+	// USBOHCI is the USB 1.1 controller.
+	USBOHCI = USBController("usbohci")
+	// USBEHCI is the USB 2.0 controller.
+	USBEHCI = USBController("usbehci")
+	// USBXHCI is the USB 3.0 controller.
+	USBXHCI = USBController("usbxhci")
+)
+
+// SetUSBController toggles the given USB controller of the machine, which
+// must not be running.
+func SetUSBController(vm string, ctl USBController, on bool) error {
+	switch ctl {
+	case USBOHCI, USBEHCI, USBXHCI:
+	default:
+		return fmt.Errorf("invalid USB controller: '%s'", ctl)
+	}
+	if err := assertMutable(vm); err != nil {
+		return err
+	}
+	return Manage().run("modifyvm", vm, "--"+string(ctl), bool2string(on))
+}
+
+// hasUSBController tells whether any USB controller of the machine is on.
+func hasUSBController(props map[string]string) bool {
+	// showvminfo reports the OHCI controller as "usb".
+	return props["usb"] == "on" || props["ehci"] == "on" || props["xhci"] == "on"
+}
@@ -2,7 +2,10 @@ package virtualbox
 
 import (
 	"context"
+	"fmt"
+	"io/ioutil"
 	"net"
+	"os"
 )
 
 // ParseIPv4Mask parses IPv4 netmask written in IP form (e.g. 255.255.255.0).
@@ -23,3 +26,63 @@ func Run(_ context.Context, args ...string) (string, string, error) {
 	// TODO: Convert the function so you can pass in the context.
 	return Manage().runOutErr(args...)
 }
+
+// checkWritableDir makes sure dir is an existing directory the current user can write into.
+func checkWritableDir(dir string) error {
+	fi, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+	if !fi.IsDir() {
+		return fmt.Errorf("'%s' is not a directory", dir)
+	}
+	f, err := ioutil.TempFile(dir, ".go-virtualbox-")
+	if err != nil {
+		return fmt.Errorf("directory '%s' is not writable: %w", dir, err)
+	}
+	name := f.Name()
+	_ = f.Close()
+	return os.Remove(name)
+}
+
+// writeSecretFile writes secret to a new temporary file only the current user
+// can read, for the VBoxManage options which take passwords from files rather
+// than from the command line. The caller removes it with removeSecretFile.
+func writeSecretFile(secret string) (string, error) {
+	f, err := ioutil.TempFile("", "go-virtualbox-")
+	if err != nil {
+		return "", err
+	}
+	err = f.Chmod(0600)
+	if err == nil {
+		_, err = f.WriteString(secret)
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// removeSecretFile overwrites the secret file written by writeSecretFile
+// before removing it, so that the secret does not linger on disk.
+func removeSecretFile(path string) error {
+	if fi, err := os.Stat(path); err == nil {
+		_ = ioutil.WriteFile(path, make([]byte, fi.Size()), 0600)
+	}
+	return os.Remove(path)
+}
+
+// withSecretFile runs fn with the path of a private temporary file holding
+// secret, which is removed when fn returns, or panics.
+func withSecretFile(secret string, fn func(path string) error) error {
+	path, err := writeSecretFile(secret)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = removeSecretFile(path) }()
+	return fn(path)
+}
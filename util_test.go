@@ -0,0 +1,47 @@
+package virtualbox
+
+import (
+	"io/ioutil"
+	"os"
+	"runtime"
+	"testing"
+)
+
+func TestWithSecretFile(t *testing.T) {
+	var secretPath string
+	err := withSecretFile("s3cr3t", func(path string) error {
+		secretPath = path
+		fi, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		if runtime.GOOS != osWindows && fi.Mode().Perm() != 0600 {
+			t.Fatalf("expected mode 0600, got %v", fi.Mode().Perm())
+		}
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if string(b) != "s3cr3t" {
+			t.Fatalf("expected the secret in %s, got %q", path, b)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(secretPath); !os.IsNotExist(err) {
+		t.Fatalf("secret file %s was not removed", secretPath)
+	}
+
+	func() {
+		defer func() { _ = recover() }()
+		_ = withSecretFile("s3cr3t", func(path string) error {
+			secretPath = path
+			panic("boom")
+		})
+	}()
+	if _, err := os.Stat(secretPath); !os.IsNotExist(err) {
+		t.Fatalf("secret file %s was not removed after a panic", secretPath)
+	}
+}
@@ -2,18 +2,27 @@ package virtualbox
 
 import (
 	"bytes"
+	"context"
 	"errors"
+	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 )
 
-type option func(Command)
+// Option customizes how the VirtualBox commands are run, see Configure.
+type Option func(Command)
 
 // Command is the mock-able interface to run VirtualBox commands
 // such as VBoxManage (host side) or VBoxControl (guest side)
 type Command interface {
-	setOpts(opts ...option) Command
+	setOpts(opts ...Option) Command
 	isGuest() bool
 	path() string
 	run(args ...string) error
@@ -28,18 +37,128 @@ var (
 	ErrMachineExist = errors.New("machine already exists")
 	// ErrMachineNotExist holds the error message when the machine does not exist.
 	ErrMachineNotExist = errors.New("machine does not exist")
+	// ErrMachineNotRunning holds the error message when the machine is expected to be running but is not.
+	ErrMachineNotRunning = errors.New("machine is not running")
+	// ErrMachineRunning holds the error message when the machine must be stopped for the operation.
+	ErrMachineRunning = errors.New("machine is running")
+	// ErrMachineLocked holds the error message when the machine settings are locked by another session.
+	ErrMachineLocked = errors.New("machine is locked by another session")
 	// ErrCommandNotFound holds the error message when the VBoxManage commands was not found.
 	ErrCommandNotFound = errors.New("command not found")
+	// ErrInvalidArgument holds the error message when a command argument is rejected before execution.
+	ErrInvalidArgument = errors.New("invalid argument")
+	// ErrClosed holds the error message when a command is run after Close.
+	ErrClosed = errors.New("virtualbox commands are closed")
+	// ErrVirtualBoxNotInstalled holds the error message when no VirtualBox program is found on PATH nor in the install folders.
+	ErrVirtualBoxNotInstalled = errors.New("VirtualBox is not installed")
 )
 
+// machineCommands are the VBoxManage commands whose first argument is a machine name or UUID.
+var machineCommands = map[string]bool{
+	"bandwidthctl":  true,
+	"clonevm":       true,
+	"controlvm":     true,
+	"debugvm":       true,
+	"discardstate":  true,
+	"export":        true,
+	"getextradata":  true,
+	"guestcontrol":  true,
+	"modifyvm":      true,
+	"showvminfo":    true,
+	"snapshot":      true,
+	"startvm":       true,
+	"storageattach": true,
+	"storagectl":    true,
+	"unregistervm":  true,
+}
+
+// validateArgs rejects arguments which cannot be passed safely to
+// exec.Command. Arguments are never interpreted by a shell, but a NUL byte
+// would silently truncate them and an empty machine name makes VBoxManage
+// pick the wrong parsing branch.
+func validateArgs(args []string) error {
+	for i, arg := range args {
+		if strings.IndexByte(arg, 0) >= 0 {
+			return fmt.Errorf("%w: argument %d contains a NUL byte", ErrInvalidArgument, i)
+		}
+	}
+	if len(args) > 1 && machineCommands[args[0]] && args[1] == "" {
+		return fmt.Errorf("%w: empty machine name for '%s'", ErrInvalidArgument, args[0])
+	}
+	return nil
+}
+
+// VBoxManage exit codes.
+const (
+	// ExitRuntimeError is the exit code of a command which failed to run.
+	ExitRuntimeError = 1
+	// ExitSyntaxError is the exit code of a command with invalid arguments.
+	ExitSyntaxError = 2
+)
+
+// VBoxError is the error returned when a VirtualBox command exits with a
+// non-zero status. It unwraps to the underlying *exec.ExitError.
+type VBoxError struct {
+	Args     []string // command arguments, without the program
+	ExitCode int
+	Stderr   string // when captured
+	Err      error
+}
+
+func (e *VBoxError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *VBoxError) Unwrap() error {
+	return e.Err
+}
+
+// ExitCode returns the exit code of the VirtualBox command behind err, or -1
+// when err is not a VBoxError.
+func ExitCode(err error) int {
+	var ve *VBoxError
+	if errors.As(err, &ve) {
+		return ve.ExitCode
+	}
+	return -1
+}
+
+// IsSyntaxError tells whether err comes from a VirtualBox command which
+// rejected its arguments.
+func IsSyntaxError(err error) bool {
+	return ExitCode(err) == ExitSyntaxError
+}
+
+// IsRuntimeError tells whether err comes from a VirtualBox command which
+// failed while running.
+func IsRuntimeError(err error) bool {
+	return ExitCode(err) == ExitRuntimeError
+}
+
+// commandError maps the error of running a VirtualBox command.
+func commandError(args []string, stderr string, err error) error {
+	if ee, ok := err.(*exec.Error); ok && ee == exec.ErrNotFound {
+		return ErrCommandNotFound
+	}
+	if ee, ok := err.(*exec.ExitError); ok {
+		return &VBoxError{Args: args, ExitCode: ee.ExitCode(), Stderr: stderr, Err: err}
+	}
+	return err
+}
+
 type command struct {
-	program string
-	sudoer  bool // Is current user a sudoer?
-	sudo    bool // Is current command expected to be run under sudo?
-	guest   bool
+	program  string
+	sudoer   bool // Is current user a sudoer?
+	sudo     bool // Is current command expected to be run under sudo?
+	guest    bool
+	ctx      context.Context   // Interrupts the command when done, if set.
+	env      map[string]string // Added to the inherited environment.
+	progress func(percent int) // Called with the progress of long operations, if set.
+	missing  error             // Returned by all the commands when no VirtualBox program was found.
 }
 
-func (vbcmd command) setOpts(opts ...option) Command {
+func (vbcmd command) setOpts(opts ...Option) Command {
 	var cmd Command = &vbcmd
 	for _, opt := range opts {
 		opt(cmd)
@@ -47,7 +166,7 @@ func (vbcmd command) setOpts(opts ...option) Command {
 	return cmd
 }
 
-func sudo(sudo bool) option {
+func sudo(sudo bool) Option {
 	return func(cmd Command) {
 		vbcmd := cmd.(*command)
 		vbcmd.sudo = sudo
@@ -55,6 +174,36 @@ func sudo(sudo bool) option {
 	}
 }
 
+func withContext(ctx context.Context) Option {
+	return func(cmd Command) {
+		vbcmd := cmd.(*command)
+		vbcmd.ctx = ctx
+	}
+}
+
+// WithEnv sets environment variables for the VirtualBox commands, on top of
+// the inherited environment, e.g. VBOX_USER_HOME to run against a separate
+// VirtualBox configuration. Note sudo may drop them, depending on its policy.
+func WithEnv(env map[string]string) Option {
+	return func(cmd Command) {
+		vbcmd := cmd.(*command)
+		vbcmd.env = make(map[string]string, len(vbcmd.env)+len(env))
+		for k, v := range vbcmd.env {
+			vbcmd.env[k] = v
+		}
+		for k, v := range env {
+			vbcmd.env[k] = v
+		}
+	}
+}
+
+func withProgress(progress func(percent int)) Option {
+	return func(cmd Command) {
+		vbcmd := cmd.(*command)
+		vbcmd.progress = progress
+	}
+}
+
 func (vbcmd command) isGuest() bool {
 	return vbcmd.guest
 }
@@ -73,53 +222,252 @@ func (vbcmd command) prepare(args []string) *exec.Cmd {
 	}
 	argv = append(argv, args...)
 	Debug("executing: %v %v", program, argv)
-	return exec.Command(program, argv...) // #nosec
+	cmd := exec.Command(program, argv...) // #nosec
+	env := localeEnv(os.Getenv("LC_ALL"))
+	for k, v := range vbcmd.env {
+		env[k] = v
+	}
+	keys := make([]string, 0, len(env))
+	for k := range env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	// Later entries win over the inherited ones.
+	cmd.Env = os.Environ()
+	for _, k := range keys {
+		cmd.Env = append(cmd.Env, k+"="+env[k])
+	}
+	return cmd
+}
+
+// localeEnv returns the environment which makes VirtualBox print untranslated
+// messages and C formatted numbers, which the parsers expect. The character
+// set is kept, so that non-ASCII arguments such as paths are converted right:
+// an inherited LC_ALL, which would override everything, is moved to LC_CTYPE.
+// WithEnv takes precedence.
+func localeEnv(lcAll string) map[string]string {
+	env := map[string]string{
+		"LANGUAGE":    "",
+		"LC_MESSAGES": "C",
+		"LC_NUMERIC":  "C",
+	}
+	if lcAll != "" {
+		env["LC_ALL"] = ""
+		env["LC_CTYPE"] = lcAll
+	}
+	return env
 }
 
 func (vbcmd command) run(args ...string) error {
+	defer invalidateAfter(args)
 	defer vbcmd.setOpts(sudo(false))
+	if err := validateArgs(args); err != nil {
+		return err
+	}
 	cmd := vbcmd.prepare(args)
 	if Verbose {
 		cmd.Stdout = os.Stdout
 		cmd.Stderr = os.Stderr
 	}
-	if err := cmd.Run(); err != nil {
-		if ee, ok := err.(*exec.Error); ok && ee == exec.ErrNotFound {
-			return ErrCommandNotFound
-		}
-		return err
+	vbcmd.watchProgress(cmd)
+	if err := vbcmd.wait(cmd); err != nil {
+		return commandError(args, "", err)
 	}
 	return nil
 }
 
 func (vbcmd command) runOut(args ...string) (string, error) {
+	defer invalidateAfter(args)
 	defer vbcmd.setOpts(sudo(false))
+	if err := validateArgs(args); err != nil {
+		return "", err
+	}
 	cmd := vbcmd.prepare(args)
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
 	if Verbose {
-		cmd.Stderr = os.Stderr
+		cmd.Stderr = io.MultiWriter(&stderr, os.Stderr)
 	}
-
-	b, err := cmd.Output()
+	vbcmd.watchProgress(cmd)
+	err := vbcmd.wait(cmd)
 	if err != nil {
-		if ee, ok := err.(*exec.Error); ok && ee == exec.ErrNotFound {
-			err = ErrCommandNotFound
-		}
+		err = commandError(args, stderr.String(), err)
 	}
-	return string(b), err
+	return stdout.String(), err
 }
 
 func (vbcmd command) runOutErr(args ...string) (string, string, error) {
+	defer invalidateAfter(args)
 	defer vbcmd.setOpts(sudo(false))
+	if err := validateArgs(args); err != nil {
+		return "", "", err
+	}
 	cmd := vbcmd.prepare(args)
 	var stdout bytes.Buffer
 	var stderr bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
-	err := cmd.Run()
+	vbcmd.watchProgress(cmd)
+	err := vbcmd.wait(cmd)
 	if err != nil {
-		if ee, ok := err.(*exec.Error); ok && ee == exec.ErrNotFound {
-			err = ErrCommandNotFound
-		}
+		err = commandError(args, stderr.String(), err)
 	}
 	return stdout.String(), stderr.String(), err
 }
+
+// interruptGracePeriod is how long a cancelled command may take to cancel its
+// operation before being killed.
+const interruptGracePeriod = 10 * time.Second
+
+// inflight tracks the running commands, for Close.
+var inflight struct {
+	sync.Mutex
+	closed bool
+	cmds   map[*exec.Cmd]chan struct{} // closed when the command exits
+}
+
+// start starts cmd and tracks it until the returned function is called.
+func start(cmd *exec.Cmd) (func(), error) {
+	inflight.Lock()
+	defer inflight.Unlock()
+	if inflight.closed {
+		return nil, ErrClosed
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	if inflight.cmds == nil {
+		inflight.cmds = map[*exec.Cmd]chan struct{}{}
+	}
+	exited := make(chan struct{})
+	inflight.cmds[cmd] = exited
+	return func() {
+		inflight.Lock()
+		delete(inflight.cmds, cmd)
+		inflight.Unlock()
+		close(exited)
+	}, nil
+}
+
+// interrupt asks a command to cancel its operation and exit.
+func interrupt(cmd *exec.Cmd) {
+	if runtime.GOOS == osWindows {
+		_ = cmd.Process.Kill()
+		return
+	}
+	_ = cmd.Process.Signal(os.Interrupt)
+}
+
+// Close stops running VirtualBox commands, e.g. when a daemon is asked to
+// shut down: commands run afterwards fail with ErrClosed, and the running ones
+// are interrupted, as if their context was cancelled. It waits for them to
+// exit until ctx is done, and then returns an error listing the commands
+// still running, which are left alone.
+func Close(ctx context.Context) error {
+	inflight.Lock()
+	inflight.closed = true
+	running := make(map[*exec.Cmd]chan struct{}, len(inflight.cmds))
+	for cmd, exited := range inflight.cmds {
+		running[cmd] = exited
+		interrupt(cmd)
+	}
+	inflight.Unlock()
+
+	var stuck []string
+	for cmd, exited := range running {
+		select {
+		case <-exited:
+		case <-ctx.Done():
+			select {
+			case <-exited:
+			default:
+				stuck = append(stuck, fmt.Sprintf("pid %d (%s)", cmd.Process.Pid, strings.Join(cmd.Args, " ")))
+			}
+		}
+	}
+	if len(stuck) > 0 {
+		sort.Strings(stuck)
+		return fmt.Errorf("%d VirtualBox commands did not exit: %s: %w", len(stuck), strings.Join(stuck, ", "), ctx.Err())
+	}
+	return nil
+}
+
+// wait runs cmd until it exits. When the command context is done, VBoxManage
+// is interrupted rather than killed, so that it cancels the ongoing operation
+// (import, teleport...) instead of leaving it running in the background.
+func (vbcmd command) wait(cmd *exec.Cmd) error {
+	if vbcmd.missing != nil {
+		return vbcmd.missing
+	}
+	if vbcmd.ctx != nil {
+		if err := vbcmd.ctx.Err(); err != nil {
+			return err
+		}
+	}
+	done, err := start(cmd)
+	if err != nil {
+		return err
+	}
+	defer done()
+	if vbcmd.ctx == nil {
+		return cmd.Wait()
+	}
+	exited := make(chan struct{})
+	defer close(exited)
+	go func() {
+		select {
+		case <-exited:
+			return
+		case <-vbcmd.ctx.Done():
+		}
+		interrupt(cmd)
+		if runtime.GOOS == osWindows {
+			return
+		}
+		select {
+		case <-exited:
+		case <-time.After(interruptGracePeriod):
+			_ = cmd.Process.Kill()
+		}
+	}()
+	return cmd.Wait()
+}
+
+// watchProgress reports the percentages VBoxManage prints on stderr for long
+// running operations, e.g. "0%...10%...20%", to the progress callback.
+func (vbcmd command) watchProgress(cmd *exec.Cmd) {
+	if vbcmd.progress == nil {
+		return
+	}
+	pw := &progressWriter{report: vbcmd.progress}
+	if cmd.Stderr == nil {
+		cmd.Stderr = pw
+	} else {
+		cmd.Stderr = io.MultiWriter(cmd.Stderr, pw)
+	}
+}
+
+// progressWriter parses the percentages written to it.
+type progressWriter struct {
+	report func(percent int)
+	digits []byte
+}
+
+func (pw *progressWriter) Write(b []byte) (int, error) {
+	for _, c := range b {
+		switch {
+		case c >= '0' && c <= '9':
+			pw.digits = append(pw.digits, c)
+		case c == '%' && len(pw.digits) > 0:
+			if n, err := strconv.Atoi(string(pw.digits)); err == nil && n <= 100 {
+				pw.report(n)
+			}
+			pw.digits = pw.digits[:0]
+		default:
+			pw.digits = pw.digits[:0]
+		}
+	}
+	return len(b), nil
+}
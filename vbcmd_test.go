@@ -0,0 +1,171 @@
+package virtualbox
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPrepareLiteralArgs(t *testing.T) {
+	vbcmd := command{program: "VBoxManage"}
+	name := `"; rm -rf /`
+	cmd := vbcmd.prepare([]string{"showvminfo", name, "--machinereadable"})
+	expected := []string{"VBoxManage", "showvminfo", name, "--machinereadable"}
+	if !reflect.DeepEqual(cmd.Args, expected) {
+		t.Fatalf("expected argv %q, got %q", expected, cmd.Args)
+	}
+	if cmd.Path == "sh" || cmd.Path == "/bin/sh" {
+		t.Fatalf("command must not run through a shell: %s", cmd.Path)
+	}
+}
+
+func TestValidateArgs(t *testing.T) {
+	if err := validateArgs([]string{"showvminfo", `"; rm -rf /`}); err != nil {
+		t.Fatal(err)
+	}
+	if err := validateArgs([]string{"showvminfo", ""}); !errors.Is(err, ErrInvalidArgument) {
+		t.Fatalf("expected ErrInvalidArgument for an empty machine name, got %v", err)
+	}
+	if err := validateArgs([]string{"modifyvm", "vm\x00", "--cpus", "2"}); !errors.Is(err, ErrInvalidArgument) {
+		t.Fatalf("expected ErrInvalidArgument for a NUL byte, got %v", err)
+	}
+	if err := (command{program: "false"}).run("controlvm", "", "poweroff"); !errors.Is(err, ErrInvalidArgument) {
+		t.Fatalf("expected ErrInvalidArgument before execution, got %v", err)
+	}
+}
+
+func TestPrepareEnv(t *testing.T) {
+	vbcmd := command{program: "VBoxManage"}
+	cmd := vbcmd.setOpts(WithEnv(map[string]string{"VBOX_USER_HOME": "/tmp/vbox"})).(*command).prepare([]string{"list", "vms"})
+	if n := len(cmd.Env); n == 0 || cmd.Env[n-1] != "VBOX_USER_HOME=/tmp/vbox" {
+		t.Fatalf("expected VBOX_USER_HOME to be set last, got %q", cmd.Env)
+	}
+	cmd = vbcmd.setOpts(WithEnv(map[string]string{"LC_MESSAGES": "de_DE.UTF-8"})).(*command).prepare([]string{"list", "vms"})
+	if lookupEnv(cmd.Env, "LC_MESSAGES") != "de_DE.UTF-8" || lookupEnv(cmd.Env, "LC_NUMERIC") != "C" {
+		t.Fatalf("expected WithEnv to override the locale, got %q", cmd.Env)
+	}
+}
+
+// lookupEnv returns the last value of key in env, as exec does.
+func lookupEnv(env []string, key string) string {
+	val := ""
+	for _, kv := range env {
+		if strings.HasPrefix(kv, key+"=") {
+			val = kv[len(key)+1:]
+		}
+	}
+	return val
+}
+
+func TestLocaleEnv(t *testing.T) {
+	env := localeEnv("fr_FR.UTF-8")
+	if env["LC_ALL"] != "" || env["LC_CTYPE"] != "fr_FR.UTF-8" || env["LC_MESSAGES"] != "C" || env["LC_NUMERIC"] != "C" {
+		t.Fatalf("unexpected locale environment: %q", env)
+	}
+	if _, ok := localeEnv("")["LC_CTYPE"]; ok {
+		t.Fatal("expected the inherited character set to be kept")
+	}
+}
+
+func TestVBoxErrorExitCode(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("no shell to fake VBoxManage")
+	}
+	vbcmd := command{program: sh}
+	_, stderr, err := vbcmd.runOutErr("-c", "echo 'Syntax error: bad option' >&2; exit 2")
+	if !IsSyntaxError(err) || IsRuntimeError(err) {
+		t.Fatalf("expected a syntax error, got %v (exit code %d)", err, ExitCode(err))
+	}
+	var ve *VBoxError
+	if !errors.As(err, &ve) || ve.Stderr != stderr || ve.Args[0] != "-c" {
+		t.Fatalf("unexpected VBoxError: %+v", ve)
+	}
+	var ee *exec.ExitError
+	if !errors.As(err, &ee) {
+		t.Fatal("VBoxError must unwrap to *exec.ExitError")
+	}
+	if err := vbcmd.run("-c", "exit 1"); !IsRuntimeError(err) {
+		t.Fatalf("expected a runtime error, got %v", err)
+	}
+	if ExitCode(errors.New("other")) != -1 {
+		t.Fatal("expected -1 for a non VBoxError")
+	}
+}
+
+func TestProgressWriter(t *testing.T) {
+	var got []int
+	pw := &progressWriter{report: func(p int) { got = append(got, p) }}
+	for _, chunk := range []string{"0%...1", "0%...20%", "...", "30%...100%\n", "Error 404%x"} {
+		if _, err := pw.Write([]byte(chunk)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expected := []int{0, 10, 20, 30, 100}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("expected %v, got %v", expected, got)
+	}
+}
+
+func TestRunInterruptedOnCancel(t *testing.T) {
+	if runtime.GOOS == osWindows {
+		t.Skip("no SIGINT on windows")
+	}
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("no shell to fake VBoxManage")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+	vbcmd := command{program: sh}
+	// VBoxManage cancels the operation and exits on SIGINT.
+	err = vbcmd.setOpts(withContext(ctx)).run("-c", "trap 'exit 3' INT; while :; do sleep 0.05; done")
+	if ExitCode(err) != 3 {
+		t.Fatalf("expected the command to be interrupted, got %v", err)
+	}
+}
+
+func TestClose(t *testing.T) {
+	if runtime.GOOS == osWindows {
+		t.Skip("no SIGINT on windows")
+	}
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("no shell to fake VBoxManage")
+	}
+	defer func() {
+		inflight.Lock()
+		inflight.closed = false
+		inflight.Unlock()
+	}()
+	vbcmd := command{program: sh}
+	running := func() int {
+		inflight.Lock()
+		defer inflight.Unlock()
+		return len(inflight.cmds)
+	}
+
+	errs := make(chan error, 2)
+	go func() { errs <- vbcmd.run("-c", "trap 'exit 3' INT; while :; do sleep 0.05; done") }()
+	go func() { errs <- vbcmd.run("-c", "trap '' INT; sleep 1") }()
+	for running() < 2 {
+		time.Sleep(10 * time.Millisecond)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	err = Close(ctx)
+	if err == nil || !strings.Contains(err.Error(), "1 VirtualBox commands did not exit") || !strings.Contains(err.Error(), "sleep 1") {
+		t.Fatalf("expected the sleep command to be reported, got %v", err)
+	}
+	if err := vbcmd.run("-c", "exit 0"); err != ErrClosed {
+		t.Fatalf("expected ErrClosed, got %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		<-errs
+	}
+}
@@ -1,12 +1,14 @@
 package virtualbox
 
 import (
+	"fmt"
 	"os"
 	"os/exec"
 	"os/user"
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"strings"
 )
 
 var (
@@ -14,10 +16,11 @@ var (
 )
 
 var (
-	reVMNameUUID      = regexp.MustCompile(`"(.+)" {([0-9a-f-]+)}`)
-	reVMInfoLine      = regexp.MustCompile(`(?:"(.+)"|(.+))=(?:"(.*)"|(.*))`)
-	reColonLine       = regexp.MustCompile(`(.+):\s+(.*)`)
-	reMachineNotFound = regexp.MustCompile(`Could not find a registered machine named '(.+)'`)
+	reVMNameUUID        = regexp.MustCompile(`"(.+)" {([0-9a-f-]+)}`)
+	reVMInfoLine        = regexp.MustCompile(`(?:"(.+)"|(.+))=(?:"(.*)"|(.*))`)
+	reColonLine         = regexp.MustCompile(`(.+):\s+(.*)`)
+	reMachineNotFound   = regexp.MustCompile(`Could not find a registered machine named '(.+)'`)
+	reMachineNotRunning = regexp.MustCompile(`Machine '(.+)' is not currently running`)
 )
 
 // Manage returns the Command to run VBoxManage/VBoxControl.
@@ -31,29 +34,71 @@ func Manage() Command {
 		Debug("Error getting sudoer status: '%v'", err)
 	}
 
-	if vbprog, err := lookupVBoxProgram("VBoxManage"); err == nil {
+	if vbprog, err := LookupVBoxManage(); err == nil {
 		manage = command{program: vbprog, sudoer: sudoer, guest: false}
-	} else if vbprog, err := lookupVBoxProgram("VBoxControl"); err == nil {
+	} else if vbprog, cerr := lookupVBoxProgram(runtime.GOOS, "VBoxControl"); cerr == nil {
 		manage = command{program: vbprog, sudoer: sudoer, guest: true}
 	} else {
 		// Did not find a VirtualBox management command
-		manage = command{program: "false", sudoer: false, guest: false}
+		manage = command{program: "VBoxManage", sudoer: false, guest: false, missing: err}
 	}
 	Debug("manage: '%+v'", manage)
 	return manage
 }
 
-func lookupVBoxProgram(vbprog string) (string, error) {
+// Configure applies the given options to all the following VirtualBox commands.
+func Configure(opts ...Option) {
+	manage = Manage().setOpts(opts...)
+}
+
+// vboxInstallDirs lists where VirtualBox installers put its programs, per host
+// OS, which may not be on PATH. Windows installers never add it.
+var vboxInstallDirs = map[string][]string{
+	"linux":   {"/usr/bin", "/usr/local/bin", "/usr/lib/virtualbox", "/opt/VirtualBox"},
+	"darwin":  {"/usr/local/bin", "/Applications/VirtualBox.app/Contents/MacOS"},
+	"freebsd": {"/usr/local/bin", "/usr/local/lib/virtualbox"},
+	osWindows: {`C:\Program Files\Oracle\VirtualBox`},
+}
 
-	if runtime.GOOS == osWindows {
-		if p := os.Getenv("VBOX_INSTALL_PATH"); p != "" {
-			vbprog = filepath.Join(p, vbprog+".exe")
-		} else {
-			vbprog = filepath.Join("C:\\", "Program Files", "Oracle", "VirtualBox", vbprog+".exe")
+// LookupVBoxManage locates VBoxManage: in the folders given by the
+// VBOX_MSI_INSTALL_PATH and VBOX_INSTALL_PATH environment variables, then on
+// PATH, then in the standard install folders of the host OS. When it is not
+// found, the error wraps ErrVirtualBoxNotInstalled and lists the places
+// searched.
+func LookupVBoxManage() (string, error) {
+	return lookupVBoxProgram(runtime.GOOS, "VBoxManage")
+}
+
+func lookupVBoxProgram(goos, vbprog string) (string, error) {
+	if goos == osWindows {
+		vbprog += ".exe"
+	}
+	var dirs []string
+	for _, env := range []string{"VBOX_MSI_INSTALL_PATH", "VBOX_INSTALL_PATH"} {
+		if dir := os.Getenv(env); dir != "" {
+			dirs = append(dirs, dir)
 		}
 	}
-
-	return exec.LookPath(vbprog)
+	searched := make([]string, 0, len(dirs)+1+len(vboxInstallDirs[goos]))
+	for _, dir := range dirs {
+		path := filepath.Join(dir, vbprog)
+		if p, err := exec.LookPath(path); err == nil {
+			return p, nil
+		}
+		searched = append(searched, path)
+	}
+	if p, err := exec.LookPath(vbprog); err == nil {
+		return p, nil
+	}
+	searched = append(searched, "$PATH")
+	for _, dir := range vboxInstallDirs[goos] {
+		path := filepath.Join(dir, vbprog)
+		if p, err := exec.LookPath(path); err == nil {
+			return p, nil
+		}
+		searched = append(searched, path)
+	}
+	return "", fmt.Errorf("%w: %s not found in %s", ErrVirtualBoxNotInstalled, vbprog, strings.Join(searched, ", "))
 }
 
 func isSudoer() (bool, error) {
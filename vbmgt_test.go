@@ -1,9 +1,13 @@
 package virtualbox
 
 import (
+	"errors"
 	"io/ioutil"
 	"os"
 	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
 
 	"github.com/golang/mock/gomock"
@@ -57,3 +61,36 @@ func TestVBMOut(t *testing.T) {
 
 	Teardown()
 }
+
+func TestLookupVBoxProgram(t *testing.T) {
+	if runtime.GOOS == osWindows {
+		t.Skip("no executable bit on windows")
+	}
+	dir, err := ioutil.TempDir("", "go-virtualbox-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := ioutil.WriteFile(filepath.Join(dir, "VBoxManage"), []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, env := range []string{"PATH", "VBOX_MSI_INSTALL_PATH", "VBOX_INSTALL_PATH"} {
+		defer os.Setenv(env, os.Getenv(env))
+		os.Unsetenv(env)
+	}
+	orig := vboxInstallDirs
+	defer func() { vboxInstallDirs = orig }()
+	vboxInstallDirs = map[string][]string{"testos": {filepath.Join(dir, "missing"), dir}}
+
+	p, err := lookupVBoxProgram("testos", "VBoxManage")
+	if err != nil || p != filepath.Join(dir, "VBoxManage") {
+		t.Fatalf("expected VBoxManage in the install folder, got '%s' (%v)", p, err)
+	}
+	_, err = lookupVBoxProgram("testos", "VBoxControl")
+	if !errors.Is(err, ErrVirtualBoxNotInstalled) || !strings.Contains(err.Error(), filepath.Join(dir, "VBoxControl")) {
+		t.Fatalf("expected ErrVirtualBoxNotInstalled listing the searched paths, got %v", err)
+	}
+	if err := (command{program: "VBoxManage", missing: err}).run("list", "vms"); !errors.Is(err, ErrVirtualBoxNotInstalled) {
+		t.Fatalf("expected the commands to fail with ErrVirtualBoxNotInstalled, got %v", err)
+	}
+}
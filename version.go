@@ -0,0 +1,55 @@
+package virtualbox
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	reVersion = regexp.MustCompile(`^(\d+)\.(\d+)\.(\d+)`)
+)
+
+var (
+	// ErrUnsupportedVersion holds the error message when the installed VirtualBox is too old for the operation.
+	ErrUnsupportedVersion = errors.New("unsupported VirtualBox version")
+)
+
+// Version returns the version reported by VBoxManage, e.g. "6.1.30r148432".
+func Version() (string, error) {
+	out, err := Manage().runOut("--version")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// versionAtLeast tells whether the installed VirtualBox is at least major.minor.
+func versionAtLeast(major, minor int) (bool, error) {
+	v, err := Version()
+	if err != nil {
+		return false, err
+	}
+	res := reVersion.FindStringSubmatch(v)
+	if res == nil {
+		return false, fmt.Errorf("unable to parse VirtualBox version '%s'", v)
+	}
+	maj, _ := strconv.Atoi(res[1])
+	min, _ := strconv.Atoi(res[2])
+	return maj > major || (maj == major && min >= minor), nil
+}
+
+// requireVersion fails with ErrUnsupportedVersion when the installed
+// VirtualBox is older than major.minor.
+func requireVersion(major, minor int, feature string) error {
+	ok, err := versionAtLeast(major, minor)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("%s requires VirtualBox %d.%d or later: %w", feature, major, minor, ErrUnsupportedVersion)
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package virtualbox
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// logPollInterval is how often FollowVMLog checks the log file for new lines.
+var logPollInterval = 250 * time.Millisecond
+
+// VMLogPath returns the path of the current VBox.log file of the machine.
+func VMLogPath(vm string) (string, error) {
+	props, err := machineProps(vm)
+	if err != nil {
+		return "", err
+	}
+	dir := props["LogFldr"]
+	if dir == "" {
+		return "", fmt.Errorf("log folder of '%s' is not reported", vm)
+	}
+	return filepath.Join(dir, "VBox.log"), nil
+}
+
+// FollowVMLog tails the VBox.log file of the machine, e.g. to watch for guru
+// meditations or device errors, sending the lines appended from now on until
+// ctx is done, when the channel is closed. When the machine restarts,
+// VirtualBox rotates the log: the rest of the old file is sent, then the new
+// file from its start.
+func FollowVMLog(ctx context.Context, vm string) (<-chan string, error) {
+	path, err := VMLogPath(vm)
+	if err != nil {
+		return nil, err
+	}
+	t := &logTail{path: path}
+	// A machine which never ran has no log yet, wait for it.
+	if err := t.open(); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if t.f != nil {
+		if _, err := t.f.Seek(0, io.SeekEnd); err != nil {
+			t.f.Close()
+			return nil, err
+		}
+	}
+
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		defer t.close()
+		tick := time.NewTicker(logPollInterval)
+		defer tick.Stop()
+		for {
+			for _, line := range t.poll() {
+				select {
+				case lines <- line:
+				case <-ctx.Done():
+					return
+				}
+			}
+			select {
+			case <-tick.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return lines, nil
+}
+
+// logTail reads the lines appended to a log file, following its rotation.
+type logTail struct {
+	path    string
+	f       *os.File
+	r       *bufio.Reader
+	partial string // last line, until its newline is written
+}
+
+func (t *logTail) open() error {
+	f, err := os.Open(t.path)
+	if err != nil {
+		return err
+	}
+	t.f, t.r, t.partial = f, bufio.NewReader(f), ""
+	return nil
+}
+
+func (t *logTail) close() {
+	if t.f != nil {
+		t.f.Close()
+		t.f = nil
+	}
+}
+
+// rotated tells whether the path now names another file than the open one.
+func (t *logTail) rotated() bool {
+	cur, err := os.Stat(t.path)
+	if err != nil {
+		return false // being rotated, check again later
+	}
+	fi, err := t.f.Stat()
+	return err != nil || !os.SameFile(fi, cur) || cur.Size() < fi.Size()
+}
+
+// poll returns the complete lines appended since the last poll.
+func (t *logTail) poll() []string {
+	if t.f == nil {
+		if t.open() != nil {
+			return nil
+		}
+	}
+	lines := t.read()
+	if t.rotated() {
+		t.close()
+		if t.open() == nil {
+			lines = append(lines, t.read()...)
+		}
+	}
+	return lines
+}
+
+func (t *logTail) read() []string {
+	var lines []string
+	for {
+		s, err := t.r.ReadString('\n')
+		t.partial += s
+		if err != nil {
+			return lines
+		}
+		lines = append(lines, strings.TrimRight(t.partial, "\r\n"))
+		t.partial = ""
+	}
+}
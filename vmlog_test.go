@@ -0,0 +1,82 @@
+package virtualbox
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFollowVMLog(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		dir, err := ioutil.TempDir("", "go-virtualbox-test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+		logFile := filepath.Join(dir, "VBox.log")
+		if err := ioutil.WriteFile(logFile, []byte("00:00:00.000000 old line\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		vmInfoOut := strings.Replace(ReadTestData("vboxmanage-showvminfo-1.out"),
+			"/Users/fix/VirtualBox VMs/go-virtualbox/Logs", dir, 1)
+		ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(1)
+
+		orig := logPollInterval
+		logPollInterval = 10 * time.Millisecond
+		defer func() { logPollInterval = orig }()
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		lines, err := FollowVMLog(ctx, VM)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		appendLog := func(s string) {
+			f, err := os.OpenFile(logFile, os.O_APPEND|os.O_WRONLY, 0644)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			if _, err := f.WriteString(s); err != nil {
+				t.Fatal(err)
+			}
+		}
+		expect := func(expected string) {
+			select {
+			case line := <-lines:
+				if line != expected {
+					t.Fatalf("expected %q, got %q", expected, line)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatalf("timed out waiting for %q", expected)
+			}
+		}
+
+		appendLog("00:00:01.000000 Guru ")
+		appendLog("Meditation\n")
+		expect("00:00:01.000000 Guru Meditation")
+
+		// Restart: VirtualBox renames the log and starts a new one.
+		appendLog("00:00:02.000000 last line\n")
+		if err := os.Rename(logFile, logFile+".1"); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(logFile, []byte("00:00:00.000000 new log\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		expect("00:00:02.000000 last line")
+		expect("00:00:00.000000 new log")
+
+		cancel()
+		for range lines {
+		}
+	}
+
+	Teardown()
+}
@@ -0,0 +1,69 @@
+package virtualbox
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SetVRDEProperty sets a property of the remote display server of the
+// machine, e.g. "TCP/Ports" or "Security/Method", live if it is running, or in
+// its settings otherwise. An empty value clears the property. The key=value
+// pair is passed as a single argument, spaces included.
+func SetVRDEProperty(vm, key, value string) error {
+	if key == "" || strings.Contains(key, "=") {
+		return fmt.Errorf("invalid VRDE property name: '%s'", key)
+	}
+	m, err := GetMachine(vm)
+	if err != nil {
+		return err
+	}
+	if m.State == Running || m.State == Paused {
+		return Manage().run("controlvm", vm, "vrdeproperty", key+"="+value)
+	}
+	return Manage().run("modifyvm", vm, "--vrdeproperty", key+"="+value)
+}
+
+// VRDEStatus describes the remote display server of a machine and its
+// clients.
+type VRDEStatus struct {
+	Enabled bool   `json:"enabled"`
+	Ports   string `json:"ports"`   // configured ports, e.g. "5000,5010-5012"
+	Port    int    `json:"port"`    // effective port, see Bound
+	Bound   bool   `json:"bound"`   // whether Port is the one the running server listens on, rather than the configured one
+	Address string `json:"address"` // listening address, empty for all
+	Active  bool   `json:"active"`  // whether a client is connected
+	Clients int    `json:"clients"` // number of client connections
+	Client  string `json:"client"`  // IP address of the last client, if any
+	User    string `json:"user"`    // user name of the last client, if any
+}
+
+// VRDEConnectionInfo reads the state of the remote display server of the
+// machine. The port is resolved to the one the server listens on when the
+// machine runs; otherwise it is the configured port, when Ports holds a
+// single one, or 0.
+func VRDEConnectionInfo(vm string) (*VRDEStatus, error) {
+	propMap, err := machineProps(vm)
+	if err != nil {
+		return nil, err
+	}
+	return parseVRDEStatus(propMap), nil
+}
+
+func parseVRDEStatus(propMap map[string]string) *VRDEStatus {
+	st := &VRDEStatus{
+		Enabled: propMap["vrde"] == "on",
+		Ports:   propMap["vrdeports"],
+		Address: propMap["vrdeaddress"],
+		Active:  propMap["VRDEActiveConnection"] == "on",
+		Client:  propMap["VRDEClientIP"],
+		User:    propMap["VRDEUser"],
+	}
+	st.Clients, _ = strconv.Atoi(propMap["VRDEClients"])
+	if port, err := strconv.Atoi(propMap["vrdeport"]); err == nil && port > 0 {
+		st.Port, st.Bound = port, true
+	} else if port, err := strconv.Atoi(st.Ports); err == nil {
+		st.Port = port
+	}
+	return st
+}
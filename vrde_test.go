@@ -0,0 +1,67 @@
+package virtualbox
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestSetVRDEProperty(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		vmInfoOut := ReadTestData("vboxmanage-showvminfo-1.out")
+		runningOut := strings.Replace(vmInfoOut, `VMState="saved"`, `VMState="running"`, 1)
+		gomock.InOrder(
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+			ManageMock.EXPECT().run("modifyvm", VM, "--vrdeproperty", "TCP/Ports=5000-5050").Return(nil).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(runningOut, "", nil).Times(1),
+			ManageMock.EXPECT().run("controlvm", VM, "vrdeproperty", "Security/Method=").Return(nil).Times(1),
+		)
+		if err := SetVRDEProperty(VM, "TCP/Ports", "5000-5050"); err != nil {
+			t.Fatal(err)
+		}
+		if err := SetVRDEProperty(VM, "Security/Method", ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := SetVRDEProperty(VM, "TCP/Ports=1", "2"); err == nil {
+		t.Fatal("expected an error for an invalid property name")
+	}
+
+	Teardown()
+}
+
+func TestVRDEConnectionInfo(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		vmInfoOut := ReadTestData("vboxmanage-showvminfo-1.out")
+		connected := strings.NewReplacer(`vrdeports="5914"`, `vrdeports="5910-5919"`, "vrdeport=-1",
+			"vrdeport=5912\nVRDEActiveConnection=\"on\"\nVRDEClients=1\nVRDEClientIP=\"10.0.0.7\"\nVRDEUser=\"ops\"").Replace(vmInfoOut)
+		gomock.InOrder(
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(vmInfoOut, "", nil).Times(1),
+			ManageMock.EXPECT().runOutErr("showvminfo", VM, "--machinereadable").Return(connected, "", nil).Times(1),
+		)
+		st, err := VRDEConnectionInfo(VM)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := VRDEStatus{Enabled: true, Ports: "5914", Port: 5914, Address: "127.0.0.1"}
+		if *st != expected {
+			t.Fatalf("expected %+v, got %+v", expected, *st)
+		}
+		st, err = VRDEConnectionInfo(VM)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected = VRDEStatus{Enabled: true, Ports: "5910-5919", Port: 5912, Bound: true, Address: "127.0.0.1",
+			Active: true, Clients: 1, Client: "10.0.0.7", User: "ops"}
+		if *st != expected {
+			t.Fatalf("expected %+v, got %+v", expected, *st)
+		}
+	}
+
+	Teardown()
+}
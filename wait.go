@@ -0,0 +1,72 @@
+package virtualbox
+
+import "time"
+
+// WaitOptions tunes how the Wait* helpers poll: every Interval at first, then
+// backing off by doubling the interval up to MaxBackoff. Short intervals react
+// faster but spawn more VBoxManage processes.
+type WaitOptions struct {
+	Interval   time.Duration // first poll period, DefaultWaitOptions.Interval when zero
+	MaxBackoff time.Duration // longest poll period, Interval when smaller
+	Timeout    time.Duration // when set, overrides the timeout argument of the helper
+}
+
+// DefaultWaitOptions are the poll settings of the Wait* helpers, unless given
+// their own WaitOptions.
+var DefaultWaitOptions = WaitOptions{
+	Interval:   250 * time.Millisecond,
+	MaxBackoff: 2 * time.Second,
+}
+
+// waitOptions merges the optional WaitOptions given to a Wait* helper, the
+// last one winning, with the defaults and the timeout argument.
+func waitOptions(timeout time.Duration, opts []WaitOptions) WaitOptions {
+	o := DefaultWaitOptions
+	o.Timeout = timeout
+	for _, opt := range opts {
+		if opt.Interval > 0 {
+			o.Interval = opt.Interval
+			o.MaxBackoff = opt.MaxBackoff
+		} else if opt.MaxBackoff > 0 {
+			o.MaxBackoff = opt.MaxBackoff
+		}
+		if opt.Timeout > 0 {
+			o.Timeout = opt.Timeout
+		}
+	}
+	if o.Interval <= 0 {
+		o.Interval = time.Second
+	}
+	if o.MaxBackoff < o.Interval {
+		o.MaxBackoff = o.Interval
+	}
+	return o
+}
+
+// poll calls done until it reports true or fails, or the timeout expires, in
+// which case it returns ErrStateTimeout. done is always called at least once,
+// and once more at the deadline.
+func (o WaitOptions) poll(done func() (bool, error)) error {
+	deadline := time.Now().Add(o.Timeout)
+	interval := o.Interval
+	for {
+		ok, err := done()
+		if err != nil {
+			return err
+		}
+		if ok {
+			return nil
+		}
+		left := time.Until(deadline)
+		if left <= 0 {
+			return ErrStateTimeout
+		}
+		if interval < left {
+			left = interval
+		}
+		time.Sleep(left)
+		if interval *= 2; interval > o.MaxBackoff {
+			interval = o.MaxBackoff
+		}
+	}
+}
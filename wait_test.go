@@ -0,0 +1,42 @@
+package virtualbox
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWaitOptions(t *testing.T) {
+	o := waitOptions(time.Minute, nil)
+	if o != (WaitOptions{Interval: DefaultWaitOptions.Interval, MaxBackoff: DefaultWaitOptions.MaxBackoff, Timeout: time.Minute}) {
+		t.Fatalf("unexpected defaults: %+v", o)
+	}
+	o = waitOptions(time.Minute, []WaitOptions{{Interval: 5 * time.Second, Timeout: time.Hour}})
+	if o.Interval != 5*time.Second || o.MaxBackoff != 5*time.Second || o.Timeout != time.Hour {
+		t.Fatalf("unexpected options: %+v", o)
+	}
+}
+
+func TestPoll(t *testing.T) {
+	calls := 0
+	start := time.Now()
+	o := WaitOptions{Interval: 10 * time.Millisecond, MaxBackoff: 40 * time.Millisecond, Timeout: time.Second}
+	err := o.poll(func() (bool, error) {
+		calls++
+		return calls == 4, nil
+	})
+	// Slept 10, 20 then 40ms.
+	if err != nil || time.Since(start) < 70*time.Millisecond {
+		t.Fatalf("expected to back off, got %v after %v", err, time.Since(start))
+	}
+
+	calls = 0
+	o.Timeout = 30 * time.Millisecond
+	if err := o.poll(func() (bool, error) { calls++; return false, nil }); err != ErrStateTimeout || calls < 2 {
+		t.Fatalf("expected ErrStateTimeout after a last check, got %v after %d calls", err, calls)
+	}
+	failed := errors.New("probe failed")
+	if err := o.poll(func() (bool, error) { return false, failed }); err != failed {
+		t.Fatalf("expected the probe error, got %v", err)
+	}
+}
@@ -0,0 +1,78 @@
+package virtualbox
+
+import (
+	"bufio"
+	"errors"
+	"regexp"
+	"strings"
+)
+
+var (
+	reExtPackRequired = regexp.MustCompile(`(?i)extension pack`)
+)
+
+var (
+	// ErrExtPackRequired holds the error message when a feature needs the VirtualBox Extension Pack.
+	ErrExtPackRequired = errors.New("VirtualBox Extension Pack is required")
+)
+
+// Webcam represents a host webcam attached to a running machine.
+type Webcam struct {
+	Path string // host device path or alias, e.g. ".0" or "/dev/video0"
+}
+
+func controlWebcam(vm string, args ...string) (string, error) {
+	stdout, stderr, err := Manage().runOutErr(append([]string{"controlvm", vm, "webcam"}, args...)...)
+	if err != nil {
+		switch {
+		case reMachineNotRunning.MatchString(stderr):
+			return "", ErrMachineNotRunning
+		case reExtPackRequired.MatchString(stderr):
+			return "", ErrExtPackRequired
+		}
+		return "", err
+	}
+	return stdout, nil
+}
+
+// AttachWebcam passes the given host webcam through to the running machine.
+// An empty device attaches the default webcam.
+func AttachWebcam(vm, device string) error {
+	args := []string{"attach"}
+	if device != "" {
+		args = append(args, device)
+	}
+	_, err := controlWebcam(vm, args...)
+	return err
+}
+
+// DetachWebcam removes the given host webcam from the running machine.
+func DetachWebcam(vm, device string) error {
+	args := []string{"detach"}
+	if device != "" {
+		args = append(args, device)
+	}
+	_, err := controlWebcam(vm, args...)
+	return err
+}
+
+// ListWebcams lists the host webcams attached to the running machine.
+func ListWebcams(vm string) ([]Webcam, error) {
+	out, err := controlWebcam(vm, "list")
+	if err != nil {
+		return nil, err
+	}
+	webcams := []Webcam{}
+	s := bufio.NewScanner(strings.NewReader(out))
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" {
+			continue
+		}
+		webcams = append(webcams, Webcam{Path: line})
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return webcams, nil
+}
@@ -0,0 +1,68 @@
+package virtualbox
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestWebcams(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		listOut := ReadTestData("vboxmanage-controlvm-webcam-list-1.out")
+		gomock.InOrder(
+			ManageMock.EXPECT().runOutErr("controlvm", VM, "webcam", "attach").Return("", "", nil).Times(1),
+			ManageMock.EXPECT().runOutErr("controlvm", VM, "webcam", "attach", "/dev/video2").Return("", "", nil).Times(1),
+			ManageMock.EXPECT().runOutErr("controlvm", VM, "webcam", "list").Return(listOut, "", nil).Times(1),
+			ManageMock.EXPECT().runOutErr("controlvm", VM, "webcam", "detach", ".1").Return("", "", nil).Times(1),
+		)
+		if err := AttachWebcam(VM, ""); err != nil {
+			t.Fatal(err)
+		}
+		if err := AttachWebcam(VM, "/dev/video2"); err != nil {
+			t.Fatal(err)
+		}
+		webcams, err := ListWebcams(VM)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected := []Webcam{{Path: ".1"}, {Path: "/dev/video2"}}; !reflect.DeepEqual(webcams, expected) {
+			t.Fatalf("expected %+v, got %+v", expected, webcams)
+		}
+		if err := DetachWebcam(VM, ".1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	Teardown()
+}
+
+func TestWebcamErrors(t *testing.T) {
+	Setup(t)
+
+	if ManageMock != nil {
+		exitErr := errors.New("exit status 1")
+		gomock.InOrder(
+			ManageMock.EXPECT().runOutErr("controlvm", VM, "webcam", "list").
+				Return("", "VBoxManage: error: Machine 'go-virtualbox' is not currently running\n", exitErr).Times(1),
+			ManageMock.EXPECT().runOutErr("controlvm", VM, "webcam", "attach").
+				Return("", "VBoxManage: error: The VirtualBox Extension Pack is not installed\n", exitErr).Times(1),
+			ManageMock.EXPECT().runOutErr("controlvm", VM, "webcam", "detach", "/dev/video9").
+				Return("", "VBoxManage: error: Webcam '/dev/video9' is not attached\n", exitErr).Times(1),
+		)
+		if _, err := ListWebcams(VM); err != ErrMachineNotRunning {
+			t.Fatalf("expected ErrMachineNotRunning, got %v", err)
+		}
+		if err := AttachWebcam(VM, ""); err != ErrExtPackRequired {
+			t.Fatalf("expected ErrExtPackRequired, got %v", err)
+		}
+		if err := DetachWebcam(VM, "/dev/video9"); err != exitErr {
+			t.Fatalf("expected the detach error, got %v", err)
+		}
+	}
+
+	Teardown()
+}